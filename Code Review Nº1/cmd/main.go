@@ -2,9 +2,14 @@ package main
 
 import (
 	"Code_Review_N_1/internal/application"
+	"Code_Review_N_1/internal/middleware"
+	"Code_Review_N_1/internal/service"
 	"fmt"
 	"github.com/joho/godotenv"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 func main() {
@@ -14,8 +19,60 @@ func main() {
 	// app
 	// - config
 	cfg := &application.ConfigDefaultInMemory{
-		FileLoader: os.Getenv("PATH_FILE_LOADER_VEHICLES"),
-		Addr:       os.Getenv("SERVER_ADDR"),
+		FileLoader:   os.Getenv("PATH_FILE_LOADER_VEHICLES"),
+		Addr:         os.Getenv("SERVER_ADDR"),
+		SynonymsFile: os.Getenv("SYNONYMS_FILE"),
+	}
+	if sunset, err := time.Parse(time.DateOnly, os.Getenv("API_V1_SUNSET")); err == nil {
+		cfg.V1Sunset = sunset
+	}
+	if ttl, err := time.ParseDuration(os.Getenv("CACHE_TTL")); err == nil {
+		cfg.CacheTTL = ttl
+	}
+	if maxAge, err := time.ParseDuration(os.Getenv("CACHE_MAX_AGE")); err == nil {
+		cfg.CacheMaxAge = maxAge
+	}
+	if ttl, err := time.ParseDuration(os.Getenv("IDEMPOTENCY_TTL")); err == nil {
+		cfg.IdempotencyTTL = ttl
+	}
+	if v := os.Getenv("RATE_LIMIT_DEFAULT"); v != "" {
+		limit, err := middleware.ParseRateLimit(v)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		cfg.RateLimit = limit
+	}
+	if v := os.Getenv("RATE_LIMIT_ROUTES"); v != "" {
+		limits, err := middleware.ParseRateLimits(v)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		cfg.RateLimitRoutes = limits
+	}
+	if v := os.Getenv("API_KEYS"); v != "" {
+		cfg.APIKeys = strings.Split(v, ",")
+	}
+	if v := os.Getenv("TRUSTED_PROXIES"); v != "" {
+		cfg.TrustedProxies = strings.Split(v, ",")
+	}
+	if v := os.Getenv("SIMILARITY_WEIGHTS"); v != "" {
+		weights, err := service.ParseSimilarityWeights(v, service.DefaultSimilarityWeights())
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		cfg.SimilarityWeights = weights
+	}
+	if allow, err := strconv.ParseBool(os.Getenv("WEBHOOK_ALLOW_PRIVATE_HOSTS")); err == nil {
+		cfg.Webhooks.AllowPrivateHosts = allow
+	}
+	if v, err := strconv.ParseFloat(os.Getenv("SIZE_COMPACT_MAX"), 64); err == nil {
+		cfg.SizeThresholds.CompactMax = v
+	}
+	if v, err := strconv.ParseFloat(os.Getenv("SIZE_LARGE_MIN"), 64); err == nil {
+		cfg.SizeThresholds.LargeMin = v
 	}
 	// - app
 	app := application.NewDefaultInMemory(cfg)
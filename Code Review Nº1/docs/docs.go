@@ -0,0 +1,103 @@
+package docs
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	// OpenAPI is the OpenAPI 3.1 document of the vehicles api.
+	//go:embed openapi.json
+	OpenAPI []byte
+	// SwaggerUI is the html page that renders the OpenAPI document.
+	//go:embed swagger.html
+	SwaggerUI []byte
+	// SwaggerUIAssets are the vendored scripts and styles of the Swagger UI page, under swagger-ui/.
+	//go:embed swagger-ui/swagger-ui.css swagger-ui/swagger-ui-bundle.js
+	SwaggerUIAssets embed.FS
+)
+
+var (
+	// ErrRoutesMismatch is returned when the registered routes and the OpenAPI document diverge.
+	ErrRoutesMismatch = errors.New("docs: registered routes and openapi document diverge")
+)
+
+// Operations returns the operations described in the OpenAPI document as "METHOD /path" (gin path syntax).
+func Operations() (ops []string, err error) {
+	// decode only the paths of the document
+	var doc struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	err = json.Unmarshal(OpenAPI, &doc)
+	if err != nil {
+		return
+	}
+
+	for path, item := range doc.Paths {
+		for method := range item {
+			// skip path level fields that are not operations
+			switch method {
+			case "get", "put", "post", "delete", "options", "head", "patch", "trace":
+			default:
+				continue
+			}
+			ops = append(ops, strings.ToUpper(method)+" "+GinPath(path))
+		}
+	}
+	sort.Strings(ops)
+	return
+}
+
+// ValidateRoutes checks that every registered route is documented and every documented operation is registered.
+func ValidateRoutes(routes gin.RoutesInfo) (err error) {
+	ops, err := Operations()
+	if err != nil {
+		return
+	}
+
+	// documented operations
+	documented := make(map[string]bool, len(ops))
+	for _, op := range ops {
+		documented[op] = true
+	}
+
+	// registered routes
+	var missing []string
+	registered := make(map[string]bool, len(routes))
+	for _, rt := range routes {
+		op := rt.Method + " " + rt.Path
+		registered[op] = true
+		if !documented[op] {
+			missing = append(missing, "undocumented route "+op)
+		}
+	}
+	for _, op := range ops {
+		if !registered[op] {
+			missing = append(missing, "unregistered operation "+op)
+		}
+	}
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		err = fmt.Errorf("%w: %s", ErrRoutesMismatch, strings.Join(missing, ", "))
+		return
+	}
+	return
+}
+
+// GinPath converts an OpenAPI path template (/vehicles/{id}) to gin syntax (/vehicles/:id).
+func GinPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		if strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}") {
+			segments[i] = ":" + s[1:len(s)-1]
+		}
+	}
+	return strings.Join(segments, "/")
+}
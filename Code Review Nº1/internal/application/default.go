@@ -1,10 +1,21 @@
 package application
 
 import (
+	"Code_Review_N_1/docs"
+	"Code_Review_N_1/internal"
+	"Code_Review_N_1/internal/bus"
 	"Code_Review_N_1/internal/handler"
 	"Code_Review_N_1/internal/loader"
+	"Code_Review_N_1/internal/middleware"
+	"Code_Review_N_1/internal/normalize"
 	"Code_Review_N_1/internal/repository"
+	"Code_Review_N_1/internal/search"
 	"Code_Review_N_1/internal/service"
+	"context"
+	"io/fs"
+	"strings"
+	"time"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -14,14 +25,88 @@ type ConfigDefaultInMemory struct {
 	FileLoader string
 	// Addr is the address where the application will be listening.
 	Addr string
+	// V1DeprecatedAt is the date when the v1 api was deprecated.
+	V1DeprecatedAt time.Time
+	// V1Sunset is the date when the v1 api will stop responding.
+	V1Sunset time.Time
+	// RateLimit is the limit of requests per client of the routes without a specific limit.
+	RateLimit middleware.RateLimit
+	// RateLimitRoutes are the limits of requests per client by route ("METHOD /gin/path").
+	RateLimitRoutes map[string]middleware.RateLimit
+	// RateLimitIdle is the time after which the rate limit of an idle client is forgotten.
+	RateLimitIdle time.Duration
+	// RateLimitClients is the maximum number of clients whose rate limit is remembered.
+	RateLimitClients int
+	// APIKeys are the api keys that identify a client for rate limiting and idempotency, any other key is ignored.
+	APIKeys []string
+	// TrustedProxies are the ips or cidrs of the proxies whose forwarded client ips are used, none when empty.
+	TrustedProxies []string
+	// CacheTTL is the time the vehicle queries are cached.
+	CacheTTL time.Duration
+	// CacheMaxAge is the time clients may reuse the responses of the vehicle queries.
+	CacheMaxAge time.Duration
+	// EventsReplay is the number of vehicle events kept to resume the change feeds.
+	EventsReplay int
+	// EventsBuffer is the number of vehicle events a change feed client may have pending before it is disconnected.
+	EventsBuffer int
+	// EventsHeartbeat is the interval of the keep alive messages of the change feeds.
+	EventsHeartbeat time.Duration
+	// Webhooks is the configuration of the webhook deliveries.
+	Webhooks service.ConfigWebhookDefault
+	// WebhookLogSize is the number of delivery attempts kept by webhook.
+	WebhookLogSize int
+	// SynonymsFile is the path to a json file with synonyms of the categorical attributes, added to the defaults.
+	SynonymsFile string
+	// SimilarityWeights are the weights of the attributes in the distance between similar vehicles.
+	SimilarityWeights internal.VehicleSimilarityWeights
+	// SizeThresholds are the footprints that separate the size classes of the vehicles.
+	SizeThresholds internal.VehicleSizeThresholds
+	// IdempotencyTTL is the time the responses of the requests with an idempotency key are kept for replay.
+	IdempotencyTTL time.Duration
+}
+
+// canonicalRoute returns the name of a route ("METHOD /gin/path") without the /v1 prefix of its alias,
+// so a limit of either /v1/vehicles or /vehicles replaces the default of both.
+func canonicalRoute(route string) string {
+	method, path, _ := strings.Cut(route, " ")
+	if rest, ok := strings.CutPrefix(path, "/v1/vehicles"); ok {
+		path = "/vehicles" + rest
+	}
+	return method + " " + path
 }
 
 // NewDefaultInMemory returns a new instance of a default application.
 func NewDefaultInMemory(c *ConfigDefaultInMemory) *DefaultInMemory {
 	// default config
 	defaultCfg := &ConfigDefaultInMemory{
-		FileLoader: "vehicles_100.json",
-		Addr:       ":8080",
+		FileLoader:     "vehicles_100.json",
+		Addr:           ":8080",
+		V1DeprecatedAt: time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC),
+		V1Sunset:       time.Date(2027, time.April, 30, 0, 0, 0, 0, time.UTC),
+		RateLimit:      middleware.RateLimit{Requests: 120, Per: time.Minute},
+		RateLimitRoutes: map[string]middleware.RateLimit{
+			"POST /vehicles/batch":       {Requests: 10, Per: time.Minute},
+			"POST /v2/vehicles/batch":    {Requests: 10, Per: time.Minute},
+			"POST /vehicles/bulk-update": {Requests: 10, Per: time.Minute},
+			"POST /vehicles/bulk-delete": {Requests: 10, Per: time.Minute},
+		},
+		RateLimitIdle:    10 * time.Minute,
+		RateLimitClients: 10000,
+		CacheTTL:         30 * time.Second,
+		EventsReplay:     256,
+		EventsBuffer:     64,
+		EventsHeartbeat:  15 * time.Second,
+		Webhooks: service.ConfigWebhookDefault{
+			Workers:     4,
+			MaxAttempts: 5,
+			Backoff:     time.Second,
+			MaxBackoff:  time.Minute,
+			Timeout:     10 * time.Second,
+		},
+		WebhookLogSize:    100,
+		SimilarityWeights: service.DefaultSimilarityWeights(),
+		SizeThresholds:    service.DefaultSizeThresholds(),
+		IdempotencyTTL:    24 * time.Hour,
 	}
 	if c != nil {
 		if c.FileLoader != "" {
@@ -30,11 +115,101 @@ func NewDefaultInMemory(c *ConfigDefaultInMemory) *DefaultInMemory {
 		if c.Addr != "" {
 			defaultCfg.Addr = c.Addr
 		}
+		if !c.V1DeprecatedAt.IsZero() {
+			defaultCfg.V1DeprecatedAt = c.V1DeprecatedAt
+		}
+		if !c.V1Sunset.IsZero() {
+			defaultCfg.V1Sunset = c.V1Sunset
+		}
+		if c.RateLimit.Requests > 0 {
+			defaultCfg.RateLimit = c.RateLimit
+		}
+		for route, limit := range c.RateLimitRoutes {
+			defaultCfg.RateLimitRoutes[canonicalRoute(route)] = limit
+		}
+		if c.RateLimitIdle > 0 {
+			defaultCfg.RateLimitIdle = c.RateLimitIdle
+		}
+		if c.RateLimitClients > 0 {
+			defaultCfg.RateLimitClients = c.RateLimitClients
+		}
+		defaultCfg.APIKeys = c.APIKeys
+		defaultCfg.TrustedProxies = c.TrustedProxies
+		if c.CacheTTL > 0 {
+			defaultCfg.CacheTTL = c.CacheTTL
+		}
+		if c.CacheMaxAge > 0 {
+			defaultCfg.CacheMaxAge = c.CacheMaxAge
+		}
+		if c.EventsReplay > 0 {
+			defaultCfg.EventsReplay = c.EventsReplay
+		}
+		if c.EventsBuffer > 0 {
+			defaultCfg.EventsBuffer = c.EventsBuffer
+		}
+		if c.EventsHeartbeat > 0 {
+			defaultCfg.EventsHeartbeat = c.EventsHeartbeat
+		}
+		if c.Webhooks.Workers > 0 {
+			defaultCfg.Webhooks.Workers = c.Webhooks.Workers
+		}
+		if c.Webhooks.MaxAttempts > 0 {
+			defaultCfg.Webhooks.MaxAttempts = c.Webhooks.MaxAttempts
+		}
+		if c.Webhooks.Backoff > 0 {
+			defaultCfg.Webhooks.Backoff = c.Webhooks.Backoff
+		}
+		if c.Webhooks.MaxBackoff > 0 {
+			defaultCfg.Webhooks.MaxBackoff = c.Webhooks.MaxBackoff
+		}
+		if c.Webhooks.Timeout > 0 {
+			defaultCfg.Webhooks.Timeout = c.Webhooks.Timeout
+		}
+		defaultCfg.Webhooks.AllowPrivateHosts = c.Webhooks.AllowPrivateHosts
+		if c.WebhookLogSize > 0 {
+			defaultCfg.WebhookLogSize = c.WebhookLogSize
+		}
+		if c.SynonymsFile != "" {
+			defaultCfg.SynonymsFile = c.SynonymsFile
+		}
+		if c.SimilarityWeights != (internal.VehicleSimilarityWeights{}) {
+			defaultCfg.SimilarityWeights = c.SimilarityWeights
+		}
+		if c.SizeThresholds.CompactMax != 0 {
+			defaultCfg.SizeThresholds.CompactMax = c.SizeThresholds.CompactMax
+		}
+		if c.SizeThresholds.LargeMin != 0 {
+			defaultCfg.SizeThresholds.LargeMin = c.SizeThresholds.LargeMin
+		}
+		if c.IdempotencyTTL > 0 {
+			defaultCfg.IdempotencyTTL = c.IdempotencyTTL
+		}
 	}
 
 	return &DefaultInMemory{
-		fileLoader: defaultCfg.FileLoader,
-		addr:       defaultCfg.Addr,
+		fileLoader:     defaultCfg.FileLoader,
+		addr:           defaultCfg.Addr,
+		v1DeprecatedAt: defaultCfg.V1DeprecatedAt,
+		v1Sunset:       defaultCfg.V1Sunset,
+		rateLimit: middleware.ConfigRateLimiter{
+			Default: defaultCfg.RateLimit,
+			Routes:  defaultCfg.RateLimitRoutes,
+			APIKeys: defaultCfg.APIKeys,
+		},
+		rateLimitIdle:     defaultCfg.RateLimitIdle,
+		rateLimitClients:  defaultCfg.RateLimitClients,
+		trustedProxies:    defaultCfg.TrustedProxies,
+		cacheTTL:          defaultCfg.CacheTTL,
+		cacheMaxAge:       defaultCfg.CacheMaxAge,
+		eventsReplay:      defaultCfg.EventsReplay,
+		eventsBuffer:      defaultCfg.EventsBuffer,
+		eventsHeartbeat:   defaultCfg.EventsHeartbeat,
+		webhooks:          defaultCfg.Webhooks,
+		webhookLogSize:    defaultCfg.WebhookLogSize,
+		synonymsFile:      defaultCfg.SynonymsFile,
+		similarityWeights: defaultCfg.SimilarityWeights,
+		sizeThresholds:    defaultCfg.SizeThresholds,
+		idempotencyTTL:    defaultCfg.IdempotencyTTL,
 	}
 }
 
@@ -44,52 +219,210 @@ type DefaultInMemory struct {
 	fileLoader string
 	// addr is the address where the application will be listening.
 	addr string
+	// v1DeprecatedAt is the date when the v1 api was deprecated.
+	v1DeprecatedAt time.Time
+	// v1Sunset is the date when the v1 api will stop responding.
+	v1Sunset time.Time
+	// rateLimit is the configuration of the rate limiter.
+	rateLimit middleware.ConfigRateLimiter
+	// rateLimitIdle is the time after which the rate limit of an idle client is forgotten.
+	rateLimitIdle time.Duration
+	// rateLimitClients is the maximum number of clients whose rate limit is remembered.
+	rateLimitClients int
+	// trustedProxies are the proxies whose forwarded client ips are used.
+	trustedProxies []string
+	// cacheTTL is the time the vehicle queries are cached.
+	cacheTTL time.Duration
+	// cacheMaxAge is the time clients may reuse the responses of the vehicle queries.
+	cacheMaxAge time.Duration
+	// eventsReplay is the number of vehicle events kept to resume the change feeds.
+	eventsReplay int
+	// eventsBuffer is the number of vehicle events a change feed client may have pending before it is disconnected.
+	eventsBuffer int
+	// eventsHeartbeat is the interval of the keep alive messages of the change feeds.
+	eventsHeartbeat time.Duration
+	// webhooks is the configuration of the webhook deliveries.
+	webhooks service.ConfigWebhookDefault
+	// webhookLogSize is the number of delivery attempts kept by webhook.
+	webhookLogSize int
+	// synonymsFile is the path to a json file with synonyms of the categorical attributes.
+	synonymsFile string
+	// similarityWeights are the weights of the attributes in the distance between similar vehicles.
+	similarityWeights internal.VehicleSimilarityWeights
+	// sizeThresholds are the footprints that separate the size classes of the vehicles.
+	sizeThresholds internal.VehicleSizeThresholds
+	// idempotencyTTL is the time the responses of the requests with an idempotency key are kept for replay.
+	idempotencyTTL time.Duration
 }
 
 // Run starts the application.
 func (d *DefaultInMemory) Run() (err error) {
+	rt, err := d.Router()
+	if err != nil {
+		return
+	}
+
+	// run application
+	err = rt.Run(d.addr)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+// Router initializes the dependencies and returns the router with every endpoint registered.
+// - the webhook deliveries start in background
+func (d *DefaultInMemory) Router() (rt *gin.Engine, err error) {
 	// dependencies initialization
+	// normalizer
+	// - the synonyms of the file are added to the defaults
+	synonyms := normalize.DefaultSynonyms()
+	if d.synonymsFile != "" {
+		synonyms, err = normalize.LoadSynonyms(d.synonymsFile)
+		if err != nil {
+			return
+		}
+	}
+	nz := normalize.NewVehicleSynonyms(synonyms)
+
 	// loader
-	ld := loader.NewVehicleJSON(d.fileLoader)
+	ld := loader.NewVehicleJSON(d.fileLoader, nz)
 	data, err := ld.Load()
 	if err != nil {
 		return
 	}
 
 	// repository
-	rp := repository.NewVehicleSlice(data.Data, data.LastId)
+	// - every mutation is indexed for the search
+	ix := search.NewVehicleInverted(nz)
+	rp, err := repository.NewVehicleIndexed(context.Background(), repository.NewVehicleSlice(data.Data, data.LastId), ix)
+	if err != nil {
+		return
+	}
+
+	// - bulk operations are audited
+	au := repository.NewVehicleAuditSlice()
+
+	// event bus
+	eb := bus.NewVehicleMemory(d.eventsReplay, d.eventsBuffer)
 
 	// service
-	sv := service.NewDefault(rp)
+	// - queries are cached and every mutation invalidates the cache
+	// - vehicles found carry their derived metrics
+	sd, err := service.NewDefault(rp, eb, nz, d.sizeThresholds, au)
+	if err != nil {
+		return
+	}
+	sv := service.NewCache(sd, nz, d.cacheTTL)
+	// - webhooks are delivered in background
+	sw := service.NewWebhookDefault(repository.NewWebhookMap(d.webhookLogSize), eb, d.webhooks)
+	go sw.Run(context.Background())
+	ss := service.NewSearch(ix)
+	sm := service.NewSimilarity(rp, d.similarityWeights)
+	sa := service.NewVehicleAudit(au)
 
 	// handler
 	hd := handler.NewVehicleDefault(sv)
+	hd2 := handler.NewVehicleV2(sv)
+	hc := handler.NewCache(sv)
+	he := handler.NewVehicleEvents(eb, nz, d.eventsHeartbeat)
+	hw := handler.NewWebhookDefault(sw)
+	hs := handler.NewVehicleSearch(ss)
+	hm := handler.NewVehicleSimilarity(sm)
+	hb := handler.NewVehicleBulk(sv, sa)
+
+	// middleware
+	vd, err := middleware.NewValidator(docs.OpenAPI)
+	if err != nil {
+		return
+	}
+	rl := middleware.NewRateLimiter(d.rateLimit, middleware.NewRateLimitStoreMemory(d.rateLimitIdle, d.rateLimitClients))
+	cc := middleware.CacheControl(d.cacheMaxAge)
+	ik := middleware.NewIdempotency(middleware.NewIdempotencyStoreMemory(), d.idempotencyTTL, d.rateLimit.APIKeys)
 
 	// router
-	rt := gin.New()
+	rt = gin.New()
+	// - the client ip is the one of the connection unless it comes from a trusted proxy
+	err = rt.SetTrustedProxies(d.trustedProxies)
+	if err != nil {
+		return
+	}
 	// - middlewares
 	rt.Use(gin.Logger())
 	rt.Use(gin.Recovery())
+	rt.Use(rl.Handler())
 	// - endpoints
-	gr := rt.Group("/vehicles")
+	// - v1 is frozen and /vehicles is an alias of /v1/vehicles
+	dp := middleware.Deprecation(d.v1DeprecatedAt, d.v1Sunset, "/v2/vehicles")
+	for _, prefix := range []string{"/v1/vehicles", "/vehicles"} {
+		gr := rt.Group(prefix)
+		gr.Use(dp, vd.Handler())
+		{
+			gr.GET("", cc, hd.GetAll())
+			gr.GET("/color/:color/year/:year", cc, hd.FindByColorAndYear())
+			gr.GET("/vehicles/brand/:brand/between/:start_year/:end_year", cc, hd.FindByBrandAndYearRange())
+			gr.GET("/average_speed/brand/:brand", cc, hd.GetAverageSpeedByBrand())
+			gr.GET("/fuel_type/:type", cc, hd.GetByFuelType())
+			gr.POST("", ik.Handler(), hd.AddVehicle())
+			gr.POST("/batch", ik.Handler(), hd.AddMultipleVehicles())
+			gr.PUT("/:id/update_speed", hd.UpdateMaxSpeed())
+			gr.DELETE("/:id", hd.DeleteVehicle())
+		}
+	}
+	// - search
+	rt.GET("/vehicles/search", vd.Handler(), cc, hs.Search())
+	// - similarity
+	rt.GET("/vehicles/:id/similar", vd.Handler(), cc, hm.Similar())
+	rt.POST("/vehicles/compare", vd.Handler(), hm.Compare())
+	// - bulk operations
+	rt.POST("/vehicles/bulk-update", vd.Handler(), hb.BulkUpdate())
+	rt.POST("/vehicles/bulk-delete", vd.Handler(), hb.BulkDelete())
+	rt.GET("/vehicles/audit", vd.Handler(), hb.Audit())
+	// - change feed
+	gre := rt.Group("/vehicles/events")
+	gre.Use(vd.Handler())
 	{
-		gr.GET("", hd.GetAll())
-		gr.GET("/color/:color/year/:year", hd.FindByColorAndYear())
-		gr.GET("/vehicles/brand/:brand/between/:start_year/:end_year", hd.FindByBrandAndYearRange())
-		gr.GET("/average_speed/brand/:brand", hd.GetAverageSpeedByBrand())
-		gr.GET("/fuel_type/:type", hd.GetByFuelType())
-		gr.POST("", hd.AddVehicle())
-		gr.POST("/batch", hd.AddMultipleVehicles())
-		gr.PUT("/:id/update_speed", hd.UpdateMaxSpeed())
-		gr.DELETE("/:id", hd.DeleteVehicle())
-
+		gre.GET("", he.SSE())
+		gre.GET("/ws", he.WebSocket())
 	}
-
-	// run application
-	err = rt.Run(d.addr)
+	// - v2
+	gr2 := rt.Group("/v2/vehicles")
+	gr2.Use(vd.HandlerWith(handler.ViolationsV2))
+	{
+		gr2.GET("", cc, hd2.List())
+		gr2.GET("/average_speed", cc, hd2.AverageSpeed())
+		gr2.GET("/:id", cc, hd2.Get())
+		gr2.POST("", hd2.Create())
+		gr2.POST("/batch", hd2.CreateBatch())
+		gr2.PATCH("/:id", hd2.Patch())
+		gr2.DELETE("/:id", hd2.Delete())
+	}
+	// - webhooks
+	grw := rt.Group("/webhooks")
+	grw.Use(vd.Handler())
+	{
+		grw.GET("", hw.GetAll())
+		grw.POST("", hw.Create())
+		grw.GET("/:id", hw.GetByID())
+		grw.DELETE("/:id", hw.Delete())
+		grw.GET("/:id/deliveries", hw.GetDeliveries())
+		grw.GET("/dead_letters", hw.GetDeadLetters())
+		grw.POST("/dead_letters/:id/retry", hw.RetryDeadLetter())
+	}
+	// - metrics
+	rt.GET("/metrics/cache", hc.Stats())
+	// - documentation
+	// - the documentation and the endpoints are checked not to diverge by the tests of this package
+	assets, err := fs.Sub(docs.SwaggerUIAssets, "swagger-ui")
 	if err != nil {
 		return
 	}
-
+	dc := handler.NewDocs(docs.OpenAPI, docs.SwaggerUI, assets)
+	rt.GET("/openapi.json", dc.OpenAPI())
+	rt.GET("/docs", dc.SwaggerUI())
+	rt.GET("/docs/assets/:file", dc.Asset())
+	// - the limits by route are shared by the aliases of the route
+	err = rl.Bind(rt.Routes())
 	return
 }
@@ -0,0 +1,258 @@
+package application_test
+
+import (
+	"Code_Review_N_1/docs"
+	"Code_Review_N_1/internal"
+	"Code_Review_N_1/internal/application"
+	"Code_Review_N_1/internal/handler"
+	"Code_Review_N_1/internal/middleware"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	app := application.NewDefaultInMemory(&application.ConfigDefaultInMemory{
+		FileLoader: "../../docs/db/vehicles_100.json",
+	})
+	rt, err := app.Router()
+	if err != nil {
+		t.Fatalf("Router() error = %v", err)
+	}
+	return rt
+}
+
+func TestDefaultInMemory_RoutesMatchOpenAPI(t *testing.T) {
+	rt := newRouter(t)
+
+	if err := docs.ValidateRoutes(rt.Routes()); err != nil {
+		t.Fatalf("ValidateRoutes() error = %v", err)
+	}
+}
+
+func TestDefaultInMemory_DocsServedLocally(t *testing.T) {
+	rt := newRouter(t)
+
+	tests := []struct {
+		name        string
+		path        string
+		code        int
+		contentType string
+	}{
+		{name: "page", path: "/docs", code: http.StatusOK, contentType: "text/html"},
+		{name: "styles", path: "/docs/assets/swagger-ui.css", code: http.StatusOK, contentType: "text/css"},
+		{name: "script", path: "/docs/assets/swagger-ui-bundle.js", code: http.StatusOK, contentType: "javascript"},
+		{name: "unknown asset", path: "/docs/assets/missing.js", code: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			res := httptest.NewRecorder()
+			rt.ServeHTTP(res, req)
+
+			if res.Code != tt.code {
+				t.Fatalf("status = %d, want %d", res.Code, tt.code)
+			}
+			if ct := res.Header().Get("Content-Type"); !strings.Contains(ct, tt.contentType) {
+				t.Errorf("Content-Type = %q, want %q", ct, tt.contentType)
+			}
+			if strings.Contains(res.Body.String(), "unpkg.com") {
+				t.Errorf("body loads assets from unpkg.com")
+			}
+		})
+	}
+}
+
+func TestDefaultInMemory_V2CreateAssignsID(t *testing.T) {
+	rt := newRouter(t)
+
+	// create, with an id the server must ignore
+	body := `{"id":1,"brand":"Ford","model":"Ka","registration":"2099-01-01","year":2001,"color":"Red","max_speed":150,` +
+		`"fuel_type":"gas","transmission":"manual","passengers":4,"height":150,"width":170,"weight":1000}`
+	req := httptest.NewRequest(http.MethodPost, "/v2/vehicles", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	res := httptest.NewRecorder()
+	rt.ServeHTTP(res, req)
+
+	if res.Code != http.StatusCreated {
+		t.Fatalf("create status = %d, want %d: %s", res.Code, http.StatusCreated, res.Body)
+	}
+	location := res.Header().Get("Location")
+	if location == "" || location == "/v2/vehicles/1" {
+		t.Fatalf("Location = %q, want the url of a new id", location)
+	}
+	created := res.Body.String()
+	if !strings.Contains(created, `"metrics":`) {
+		t.Fatalf("create body = %s, want the vehicle with its metrics", created)
+	}
+
+	// get
+	req = httptest.NewRequest(http.MethodGet, location, nil)
+	res = httptest.NewRecorder()
+	rt.ServeHTTP(res, req)
+
+	if res.Code != http.StatusOK || res.Body.String() != created {
+		t.Fatalf("get %s = %d %s, want the created vehicle %s", location, res.Code, res.Body, created)
+	}
+}
+
+func TestDefaultInMemory_SimilarK(t *testing.T) {
+	rt := newRouter(t)
+
+	tests := []struct {
+		query string
+		code  int
+	}{
+		{query: "k=0", code: http.StatusBadRequest},
+		{query: "k=-3", code: http.StatusBadRequest},
+		{query: "k=2", code: http.StatusOK},
+		{query: "k=1000", code: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/vehicles/1/similar?"+tt.query, nil)
+			res := httptest.NewRecorder()
+			rt.ServeHTTP(res, req)
+
+			if res.Code != tt.code {
+				t.Fatalf("status = %d, want %d: %s", res.Code, tt.code, res.Body)
+			}
+		})
+	}
+}
+
+func TestDefaultInMemory_V2ListRejectedByValidator(t *testing.T) {
+	rt := newRouter(t)
+
+	tests := []struct {
+		query     string
+		violation string
+	}{
+		{query: "sort=bogus", violation: `"name":"sort"`},
+		{query: "size_class=huge", violation: `"name":"size_class"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v2/vehicles?"+tt.query, nil)
+			res := httptest.NewRecorder()
+			rt.ServeHTTP(res, req)
+
+			if res.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want %d: %s", res.Code, http.StatusBadRequest, res.Body)
+			}
+			if body := res.Body.String(); !strings.Contains(body, `"code":"invalid_request"`) || !strings.Contains(body, tt.violation) {
+				t.Fatalf("body = %s, want a violation of %s", body, tt.violation)
+			}
+		})
+	}
+}
+
+func TestDefaultInMemory_RateLimitSharedByAliases(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	app := application.NewDefaultInMemory(&application.ConfigDefaultInMemory{
+		FileLoader: "../../docs/db/vehicles_100.json",
+		// overrides the default limit of /vehicles/batch, its alias
+		RateLimitRoutes: map[string]middleware.RateLimit{"POST /v1/vehicles/batch": {Requests: 1, Per: time.Hour}},
+	})
+	rt, err := app.Router()
+	if err != nil {
+		t.Fatalf("Router() error = %v", err)
+	}
+
+	post := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(`[]`))
+		req.Header.Set("Content-Type", "application/json")
+		res := httptest.NewRecorder()
+		rt.ServeHTTP(res, req)
+		return res
+	}
+
+	if res := post("/vehicles/batch"); res.Code == http.StatusTooManyRequests || res.Header().Get("RateLimit-Limit") != "1" {
+		t.Fatalf("first request = %d with limit %q, want the limit of 1", res.Code, res.Header().Get("RateLimit-Limit"))
+	}
+	if res := post("/v1/vehicles/batch"); res.Code != http.StatusTooManyRequests {
+		t.Fatalf("second request, through the alias, status = %d, want %d", res.Code, http.StatusTooManyRequests)
+	}
+}
+
+func TestDefaultInMemory_InvalidSizeThresholds(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	app := application.NewDefaultInMemory(&application.ConfigDefaultInMemory{
+		FileLoader: "../../docs/db/vehicles_100.json",
+		// above the default large min
+		SizeThresholds: internal.VehicleSizeThresholds{CompactMax: 50000},
+	})
+
+	if _, err := app.Router(); !errors.Is(err, internal.ErrServiceVehicleSizeThresholdsInvalid) {
+		t.Fatalf("Router() error = %v, want %v", err, internal.ErrServiceVehicleSizeThresholdsInvalid)
+	}
+}
+
+func TestDefaultInMemory_BulkUpdateAudited(t *testing.T) {
+	rt := newRouter(t)
+
+	do := func(method, path, body string) (int, string) {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		res := httptest.NewRecorder()
+		rt.ServeHTTP(res, req)
+		return res.Code, res.Body.String()
+	}
+	audit := func() []handler.VehicleAuditEntryJSON {
+		t.Helper()
+		code, body := do(http.MethodGet, "/vehicles/audit", "")
+		var res struct {
+			Data []handler.VehicleAuditEntryJSON `json:"data"`
+		}
+		if err := json.Unmarshal([]byte(body), &res); code != http.StatusOK || err != nil {
+			t.Fatalf("audit = %d %s, want the entries", code, body)
+		}
+		return res.Data
+	}
+	vehicles := func() string {
+		t.Helper()
+		_, body := do(http.MethodGet, "/vehicles", "")
+		return body
+	}
+	var result struct {
+		Data handler.BulkResultJSON `json:"data"`
+	}
+	before := vehicles()
+
+	// invalid patch
+	if code, body := do(http.MethodPost, "/vehicles/bulk-update", `{"filter":{"year_from":1900},"patch":{"color":""}}`); code != http.StatusBadRequest {
+		t.Fatalf("invalid update = %d %s, want %d", code, body, http.StatusBadRequest)
+	}
+
+	// dry run
+	code, body := do(http.MethodPost, "/vehicles/bulk-update", `{"filter":{"year_from":1900},"patch":{"color":"Gold"},"dry_run":true}`)
+	if err := json.Unmarshal([]byte(body), &result); code != http.StatusOK || err != nil || !result.Data.DryRun || result.Data.Count == 0 {
+		t.Fatalf("dry run = %d %s, want the matched vehicles", code, body)
+	}
+	matched := result.Data.IDs
+	if vehicles() != before {
+		t.Fatalf("vehicles changed without being updated")
+	}
+	if entries := audit(); len(entries) != 0 {
+		t.Fatalf("audit = %+v, want no entries", entries)
+	}
+
+	// update
+	code, body = do(http.MethodPost, "/vehicles/bulk-update", `{"filter":{"year_from":1900},"patch":{"color":"Gold"}}`)
+	if err := json.Unmarshal([]byte(body), &result); code != http.StatusOK || err != nil || result.Data.DryRun || !slices.Equal(result.Data.IDs, matched) {
+		t.Fatalf("update = %d %s, want the vehicles of the dry run %v", code, body, matched)
+	}
+	if entries := audit(); len(entries) != 1 || entries[0].Action != internal.VehicleAuditBulkUpdate || !slices.Equal(entries[0].IDs, matched) {
+		t.Fatalf("audit = %+v, want a single bulk update entry of %v", entries, matched)
+	}
+}
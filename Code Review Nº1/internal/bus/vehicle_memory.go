@@ -0,0 +1,149 @@
+package bus
+
+import (
+	"Code_Review_N_1/internal"
+	"sync"
+	"time"
+)
+
+// NewVehicleMemory returns a new instance of an in-memory vehicle event bus.
+// - replay is the number of events kept to resume subscriptions
+// - buffer is the number of events a subscriber may have pending before it is dropped
+func NewVehicleMemory(replay, buffer int) *VehicleMemory {
+	return &VehicleMemory{
+		replay:      make([]internal.VehicleEvent, 0, replay),
+		replaySize:  replay,
+		buffer:      buffer,
+		subscribers: make(map[*subscriber]struct{}),
+	}
+}
+
+// subscriber is an struct that represents a subscription to the bus.
+type subscriber struct {
+	// ch is the channel where the events are delivered.
+	ch chan internal.VehicleEvent
+	// filter selects the events delivered to the subscriber.
+	filter internal.VehicleEventFilter
+}
+
+// VehicleMemory is an struct that represents an in-memory vehicle event bus.
+// - slow subscribers are dropped instead of blocking the publishers, they can resume with the last event id
+type VehicleMemory struct {
+	// mu guards the fields below.
+	mu sync.Mutex
+	// lastID is the id of the last published event.
+	lastID uint64
+	// replay is the ring of the last published events, oldest first.
+	replay []internal.VehicleEvent
+	// replaySize is the capacity of replay.
+	replaySize int
+	// buffer is the capacity of the channel of every subscriber.
+	buffer int
+	// subscribers are the active subscriptions.
+	subscribers map[*subscriber]struct{}
+}
+
+// Publish assigns an id to the event and delivers it to the subscribers.
+func (b *VehicleMemory) Publish(e internal.VehicleEvent) internal.VehicleEvent {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// assign id
+	b.lastID++
+	e.ID = b.lastID
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	// keep for replay
+	if b.replaySize > 0 {
+		if len(b.replay) == b.replaySize {
+			b.replay = append(b.replay[:0], b.replay[1:]...)
+		}
+		b.replay = append(b.replay, e)
+	}
+
+	// deliver
+	for sub := range b.subscribers {
+		b.deliver(sub, e)
+	}
+	return e
+}
+
+// Subscribe returns the events matching filter published after lastID, replaying the buffered ones.
+// - when some of the events after lastID are no longer in the replay ring, or lastID was never published,
+// a reset event with the last id is sent instead of the replay, whatever the filter
+func (b *VehicleMemory) Subscribe(lastID uint64, filter internal.VehicleEventFilter) (events <-chan internal.VehicleEvent, cancel func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// the replayed events, or the reset, do not count against the buffer
+	gap := lastID > 0 && b.gap(lastID)
+	pending := len(b.replay)
+	if gap {
+		pending = 1
+	}
+	sub := &subscriber{
+		ch:     make(chan internal.VehicleEvent, b.buffer+pending),
+		filter: filter,
+	}
+	b.subscribers[sub] = struct{}{}
+
+	// replay the events missed by the subscriber
+	switch {
+	case gap:
+		sub.ch <- internal.VehicleEvent{ID: b.lastID, Type: internal.VehicleEventReset, Time: time.Now()}
+	case lastID > 0:
+		for _, e := range b.replay {
+			if e.ID > lastID {
+				b.deliver(sub, e)
+			}
+		}
+	}
+
+	cancel = func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.drop(sub)
+	}
+	return sub.ch, cancel
+}
+
+// gap reports whether the events published after lastID can not be replayed.
+func (b *VehicleMemory) gap(lastID uint64) bool {
+	switch {
+	case lastID > b.lastID:
+		// from another run of the bus
+		return true
+	case lastID == b.lastID:
+		return false
+	case len(b.replay) == 0:
+		return true
+	default:
+		return b.replay[0].ID > lastID+1
+	}
+}
+
+// deliver sends an event to a subscriber, dropping it when its buffer is full.
+func (b *VehicleMemory) deliver(sub *subscriber, e internal.VehicleEvent) {
+	if _, ok := b.subscribers[sub]; !ok {
+		return
+	}
+	if sub.filter != nil && !sub.filter(e) {
+		return
+	}
+	select {
+	case sub.ch <- e:
+	default:
+		b.drop(sub)
+	}
+}
+
+// drop removes a subscriber and closes its channel.
+func (b *VehicleMemory) drop(sub *subscriber) {
+	if _, ok := b.subscribers[sub]; !ok {
+		return
+	}
+	delete(b.subscribers, sub)
+	close(sub.ch)
+}
@@ -0,0 +1,144 @@
+package bus_test
+
+import (
+	"Code_Review_N_1/internal"
+	"Code_Review_N_1/internal/bus"
+	"slices"
+	"testing"
+)
+
+// event returns an event of a vehicle of brand.
+func event(brand string) internal.VehicleEvent {
+	return internal.VehicleEvent{Type: internal.VehicleEventCreated, Vehicle: internal.Vehicle{Normalized: internal.VehicleCategories{Brand: brand}}}
+}
+
+// receive returns the events pending in ch, and whether ch is closed.
+func receive(ch <-chan internal.VehicleEvent) (events []internal.VehicleEvent, closed bool) {
+	for {
+		select {
+		case e, ok := <-ch:
+			if !ok {
+				return events, true
+			}
+			events = append(events, e)
+		default:
+			return events, false
+		}
+	}
+}
+
+// ids returns the ids and types of the events.
+func ids(events []internal.VehicleEvent) (ids []uint64, types []string) {
+	for _, e := range events {
+		ids = append(ids, e.ID)
+		types = append(types, e.Type)
+	}
+	return
+}
+
+func TestVehicleMemory_Subscribe(t *testing.T) {
+	fords := func(e internal.VehicleEvent) bool { return e.Vehicle.Normalized.Brand == "Ford" }
+	reset := []string{internal.VehicleEventReset}
+
+	tests := []struct {
+		name string
+		// published are the brands of the events published before subscribing, with ids from 1
+		published []string
+		replay    int
+		lastID    uint64
+		filter    internal.VehicleEventFilter
+		wantIDs   []uint64
+		// wantTypes defaults to created events
+		wantTypes []string
+	}{
+		{name: "new subscription gets no past events", published: []string{"Ford", "Fiat"}, replay: 4},
+		{name: "replay after last id", published: []string{"Ford", "Fiat", "Ford"}, replay: 4, lastID: 1, wantIDs: []uint64{2, 3}},
+		{name: "replay with filter", published: []string{"Ford", "Fiat", "Ford"}, replay: 4, lastID: 1, filter: fords, wantIDs: []uint64{3}},
+		{name: "up to date", published: []string{"Ford", "Fiat"}, replay: 4, lastID: 2},
+		{name: "last id still in the ring", published: []string{"Ford", "Fiat", "Ford", "Fiat"}, replay: 2, lastID: 2, wantIDs: []uint64{3, 4}},
+		{name: "ring overflow", published: []string{"Ford", "Fiat", "Ford", "Fiat"}, replay: 2, lastID: 1, wantIDs: []uint64{4}, wantTypes: reset},
+		{name: "ring overflow ignores the filter", published: []string{"Fiat", "Fiat", "Fiat"}, replay: 1, lastID: 1, filter: fords, wantIDs: []uint64{3}, wantTypes: reset},
+		{name: "without replay", published: []string{"Ford", "Fiat"}, replay: 0, lastID: 1, wantIDs: []uint64{2}, wantTypes: reset},
+		{name: "last id of another run", published: []string{"Ford"}, replay: 4, lastID: 9, wantIDs: []uint64{1}, wantTypes: reset},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := bus.NewVehicleMemory(tt.replay, 4)
+			for _, brand := range tt.published {
+				b.Publish(event(brand))
+			}
+
+			ch, cancel := b.Subscribe(tt.lastID, tt.filter)
+			defer cancel()
+			events, closed := receive(ch)
+
+			gotIDs, gotTypes := ids(events)
+			wantTypes := tt.wantTypes
+			if wantTypes == nil {
+				for range tt.wantIDs {
+					wantTypes = append(wantTypes, internal.VehicleEventCreated)
+				}
+			}
+			if closed || !slices.Equal(gotIDs, tt.wantIDs) || !slices.Equal(gotTypes, wantTypes) {
+				t.Fatalf("events = %v %v (closed %t), want %v %v", gotIDs, gotTypes, closed, tt.wantIDs, wantTypes)
+			}
+		})
+	}
+}
+
+func TestVehicleMemory_Publish(t *testing.T) {
+	b := bus.NewVehicleMemory(0, 4)
+	all, cancelAll := b.Subscribe(0, nil)
+	defer cancelAll()
+	fords, cancelFords := b.Subscribe(0, func(e internal.VehicleEvent) bool { return e.Vehicle.Normalized.Brand == "Ford" })
+	defer cancelFords()
+
+	for _, brand := range []string{"Ford", "Fiat", "Ford"} {
+		b.Publish(event(brand))
+	}
+
+	if events, _ := receive(all); len(events) != 3 {
+		t.Fatalf("unfiltered subscriber got %d events, want 3", len(events))
+	}
+	events, _ := receive(fords)
+	if got, _ := ids(events); !slices.Equal(got, []uint64{1, 3}) {
+		t.Fatalf("filtered subscriber got %v, want [1 3]", got)
+	}
+}
+
+func TestVehicleMemory_SlowSubscriberDropped(t *testing.T) {
+	b := bus.NewVehicleMemory(8, 2)
+	ch, cancel := b.Subscribe(0, nil)
+	defer cancel()
+
+	for i := 0; i < 3; i++ {
+		b.Publish(event("Ford"))
+	}
+
+	events, closed := receive(ch)
+	if got, _ := ids(events); !closed || !slices.Equal(got, []uint64{1, 2}) {
+		t.Fatalf("events = %v (closed %t), want [1 2] and the channel closed", got, closed)
+	}
+
+	// resumes from the last received event
+	ch, cancel = b.Subscribe(2, nil)
+	defer cancel()
+	events, _ = receive(ch)
+	if got, _ := ids(events); !slices.Equal(got, []uint64{3}) {
+		t.Fatalf("resumed events = %v, want [3]", got)
+	}
+}
+
+func TestVehicleMemory_Cancel(t *testing.T) {
+	b := bus.NewVehicleMemory(8, 2)
+	ch, cancel := b.Subscribe(0, nil)
+
+	cancel()
+	b.Publish(event("Ford"))
+	// cancelling twice is harmless
+	cancel()
+
+	if events, closed := receive(ch); !closed || len(events) != 0 {
+		t.Fatalf("events = %v (closed %t), want none and the channel closed", events, closed)
+	}
+}
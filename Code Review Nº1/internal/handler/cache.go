@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"Code_Review_N_1/internal"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CacheStatsJSON is an struct that represents the metrics of a cache in json format.
+type CacheStatsJSON struct {
+	Hits          uint64  `json:"hits"`
+	Misses        uint64  `json:"misses"`
+	HitRatio      float64 `json:"hit_ratio"`
+	Invalidations uint64  `json:"invalidations"`
+	Entries       int     `json:"entries"`
+}
+
+// NewCache returns a new instance of a cache metrics handler.
+func NewCache(cm internal.CacheMetrics) *Cache {
+	return &Cache{cm: cm}
+}
+
+// Cache is an struct that contains handlers for the cache metrics.
+type Cache struct {
+	cm internal.CacheMetrics
+}
+
+// Stats returns the current metrics of the cache.
+func (c *Cache) Stats() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// process
+		st := c.cm.Stats()
+
+		// response
+		data := CacheStatsJSON{
+			Hits:          st.Hits,
+			Misses:        st.Misses,
+			Invalidations: st.Invalidations,
+			Entries:       st.Entries,
+		}
+		if total := st.Hits + st.Misses; total > 0 {
+			data.HitRatio = float64(st.Hits) / float64(total)
+		}
+		ctx.JSON(http.StatusOK, gin.H{"message": "success to get cache stats", "data": data})
+	}
+}
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NewDocs returns a new instance of a documentation handler.
+// - assets are the files the ui page loads, served by name
+func NewDocs(spec []byte, ui []byte, assets fs.FS) *Docs {
+	return &Docs{spec: spec, ui: ui, assets: assets}
+}
+
+// Docs is an struct that contains handlers for the api documentation.
+type Docs struct {
+	// spec is the OpenAPI document.
+	spec []byte
+	// ui is the html page that renders the OpenAPI document.
+	ui []byte
+	// assets are the files the ui page loads.
+	assets fs.FS
+}
+
+// OpenAPI returns the OpenAPI document.
+func (d *Docs) OpenAPI() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Data(http.StatusOK, "application/json; charset=utf-8", d.spec)
+	}
+}
+
+// SwaggerUI returns the Swagger UI page.
+func (d *Docs) SwaggerUI() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Data(http.StatusOK, "text/html; charset=utf-8", d.ui)
+	}
+}
+
+// Asset returns a file loaded by the Swagger UI page.
+func (d *Docs) Asset() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// request
+		name := ctx.Param("file")
+
+		// process
+		b, err := fs.ReadFile(d.assets, name)
+		if err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"message": "asset not found"})
+			return
+		}
+
+		// response
+		contentType := mime.TypeByExtension(path.Ext(name))
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		ctx.Header("Cache-Control", "public, max-age=86400")
+		ctx.Data(http.StatusOK, contentType, b)
+	}
+}
@@ -0,0 +1,185 @@
+package handler
+
+import (
+	"Code_Review_N_1/internal"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VehicleFilterJSON is an struct that represents the filter of a bulk operation in json format.
+type VehicleFilterJSON struct {
+	Brand        string `json:"brand,omitempty"`
+	Color        string `json:"color,omitempty"`
+	FuelType     string `json:"fuel_type,omitempty"`
+	Transmission string `json:"transmission,omitempty"`
+	YearFrom     int    `json:"year_from,omitempty"`
+	YearTo       int    `json:"year_to,omitempty"`
+	MaxSpeedMin  int    `json:"max_speed_min,omitempty"`
+	MaxSpeedMax  int    `json:"max_speed_max,omitempty"`
+}
+
+// VehiclePatchJSON is an struct that represents the patch of a bulk update in json format.
+type VehiclePatchJSON struct {
+	MaxSpeed     *int    `json:"max_speed,omitempty"`
+	Color        *string `json:"color,omitempty"`
+	FuelType     *string `json:"fuel_type,omitempty"`
+	Transmission *string `json:"transmission,omitempty"`
+}
+
+// BulkUpdateRequestJSON is an struct that represents the body of a bulk update in json format.
+type BulkUpdateRequestJSON struct {
+	Filter VehicleFilterJSON `json:"filter"`
+	Patch  VehiclePatchJSON  `json:"patch"`
+	DryRun bool              `json:"dry_run"`
+}
+
+// BulkDeleteRequestJSON is an struct that represents the body of a bulk delete in json format.
+type BulkDeleteRequestJSON struct {
+	Filter VehicleFilterJSON `json:"filter"`
+	DryRun bool              `json:"dry_run"`
+}
+
+// BulkResultJSON is an struct that represents the vehicles affected by a bulk operation in json format.
+type BulkResultJSON struct {
+	DryRun bool  `json:"dry_run"`
+	Count  int   `json:"count"`
+	IDs    []int `json:"ids"`
+}
+
+// VehicleAuditEntryJSON is an struct that represents an entry of the vehicle audit log in json format.
+type VehicleAuditEntryJSON struct {
+	ID     int               `json:"id"`
+	Time   time.Time         `json:"time"`
+	Action string            `json:"action"`
+	Filter VehicleFilterJSON `json:"filter"`
+	Patch  *VehiclePatchJSON `json:"patch,omitempty"`
+	IDs    []int             `json:"ids"`
+}
+
+// NewVehicleBulk returns a new instance of a vehicle bulk operations handler.
+func NewVehicleBulk(sv internal.ServiceVehicle, sa internal.ServiceVehicleAudit) *VehicleBulk {
+	return &VehicleBulk{sv: sv, sa: sa}
+}
+
+// VehicleBulk is an struct that contains handlers for the bulk operations over vehicles and their audit log.
+// - bodies are validated by the validator middleware
+type VehicleBulk struct {
+	sv internal.ServiceVehicle
+	sa internal.ServiceVehicleAudit
+}
+
+func convertJSONToFilter(f VehicleFilterJSON) internal.VehicleFilter {
+	return internal.VehicleFilter{
+		Brand:        f.Brand,
+		Color:        f.Color,
+		FuelType:     f.FuelType,
+		Transmission: f.Transmission,
+		YearFrom:     f.YearFrom,
+		YearTo:       f.YearTo,
+		MaxSpeedMin:  f.MaxSpeedMin,
+		MaxSpeedMax:  f.MaxSpeedMax,
+	}
+}
+
+func convertFilterToJSON(f internal.VehicleFilter) VehicleFilterJSON {
+	return VehicleFilterJSON{
+		Brand:        f.Brand,
+		Color:        f.Color,
+		FuelType:     f.FuelType,
+		Transmission: f.Transmission,
+		YearFrom:     f.YearFrom,
+		YearTo:       f.YearTo,
+		MaxSpeedMin:  f.MaxSpeedMin,
+		MaxSpeedMax:  f.MaxSpeedMax,
+	}
+}
+
+// bulkError writes the error of a bulk operation.
+func bulkError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, internal.ErrServiceVehicleBulkInvalid):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to apply bulk operation"})
+	}
+}
+
+// BulkUpdate applies a patch to every vehicle matching a filter.
+func (c *VehicleBulk) BulkUpdate() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// request
+		var body BulkUpdateRequestJSON
+		if err := ctx.ShouldBindJSON(&body); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
+			return
+		}
+		patch := internal.VehiclePatch{
+			MaxSpeed:     body.Patch.MaxSpeed,
+			Color:        body.Patch.Color,
+			FuelType:     body.Patch.FuelType,
+			Transmission: body.Patch.Transmission,
+		}
+
+		// process
+		ids, err := c.sv.BulkUpdate(ctx.Request.Context(), convertJSONToFilter(body.Filter), patch, body.DryRun)
+		if err != nil {
+			bulkError(ctx, err)
+			return
+		}
+
+		// response
+		ctx.JSON(http.StatusOK, gin.H{"message": "success to update vehicles", "data": BulkResultJSON{DryRun: body.DryRun, Count: len(ids), IDs: ids}})
+	}
+}
+
+// BulkDelete removes every vehicle matching a filter.
+func (c *VehicleBulk) BulkDelete() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// request
+		var body BulkDeleteRequestJSON
+		if err := ctx.ShouldBindJSON(&body); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
+			return
+		}
+
+		// process
+		ids, err := c.sv.BulkDelete(ctx.Request.Context(), convertJSONToFilter(body.Filter), body.DryRun)
+		if err != nil {
+			bulkError(ctx, err)
+			return
+		}
+
+		// response
+		ctx.JSON(http.StatusOK, gin.H{"message": "success to delete vehicles", "data": BulkResultJSON{DryRun: body.DryRun, Count: len(ids), IDs: ids}})
+	}
+}
+
+// Audit returns the log of the bulk operations, newest first.
+func (c *VehicleBulk) Audit() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// process
+		entries, err := c.sa.FindAll(ctx.Request.Context())
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": "internal server error"})
+			return
+		}
+
+		// response
+		data := make([]VehicleAuditEntryJSON, len(entries))
+		for i, e := range entries {
+			data[i] = VehicleAuditEntryJSON{ID: e.ID, Time: e.Time, Action: e.Action, Filter: convertFilterToJSON(e.Filter), IDs: e.IDs}
+			if e.Patch != nil {
+				data[i].Patch = &VehiclePatchJSON{
+					MaxSpeed:     e.Patch.MaxSpeed,
+					Color:        e.Patch.Color,
+					FuelType:     e.Patch.FuelType,
+					Transmission: e.Patch.Transmission,
+				}
+			}
+		}
+		ctx.JSON(http.StatusOK, gin.H{"message": "success to find audit entries", "data": data})
+	}
+}
@@ -2,9 +2,9 @@ package handler
 
 import (
 	"Code_Review_N_1/internal"
+	"Code_Review_N_1/internal/middleware"
 	"errors"
 	"net/http"
-	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -32,28 +32,34 @@ func NewVehicleDefault(sv internal.ServiceVehicle) *VehicleDefault {
 }
 
 // VehicleDefault is an struct that contains handlers for vehicle.
+// - path and form parameters are validated and parsed by the validator middleware
+// - the creations are served behind the idempotency middleware, so retries with an Idempotency-Key do not duplicate vehicles
 type VehicleDefault struct {
 	sv internal.ServiceVehicle
 }
 
+func convertVehicleToJSON(vehicle internal.Vehicle) VehicleJSON {
+	return VehicleJSON{
+		ID:           vehicle.ID,
+		Brand:        vehicle.Attributes.Brand,
+		Model:        vehicle.Attributes.Model,
+		Registration: vehicle.Attributes.Registration,
+		Year:         vehicle.Attributes.Year,
+		Color:        vehicle.Attributes.Color,
+		MaxSpeed:     vehicle.Attributes.MaxSpeed,
+		FuelType:     vehicle.Attributes.FuelType,
+		Transmission: vehicle.Attributes.Transmission,
+		Passengers:   vehicle.Attributes.Passengers,
+		Height:       vehicle.Attributes.Height,
+		Width:        vehicle.Attributes.Width,
+		Weight:       vehicle.Attributes.Weight,
+	}
+}
+
 func convertVehiclesToJSON(vehicles []internal.Vehicle) []VehicleJSON {
 	data := make([]VehicleJSON, len(vehicles))
 	for i, vehicle := range vehicles {
-		data[i] = VehicleJSON{
-			ID:           vehicle.ID,
-			Brand:        vehicle.Attributes.Brand,
-			Model:        vehicle.Attributes.Model,
-			Registration: vehicle.Attributes.Registration,
-			Year:         vehicle.Attributes.Year,
-			Color:        vehicle.Attributes.Color,
-			MaxSpeed:     vehicle.Attributes.MaxSpeed,
-			FuelType:     vehicle.Attributes.FuelType,
-			Transmission: vehicle.Attributes.Transmission,
-			Passengers:   vehicle.Attributes.Passengers,
-			Height:       vehicle.Attributes.Height,
-			Width:        vehicle.Attributes.Width,
-			Weight:       vehicle.Attributes.Weight,
-		}
+		data[i] = convertVehicleToJSON(vehicle)
 	}
 	return data
 }
@@ -65,7 +71,7 @@ func (c *VehicleDefault) GetAll() gin.HandlerFunc {
 		// ...
 		// process
 		// - get all vehicles from the service
-		vehicles, err := c.sv.FindAll()
+		vehicles, err := c.sv.FindAll(ctx.Request.Context())
 		if err != nil {
 			switch {
 			case errors.Is(err, internal.ErrServiceVehicleNotFound):
@@ -92,25 +98,28 @@ func (c *VehicleDefault) AddVehicle() gin.HandlerFunc {
 			return
 		}
 
-		if err := c.sv.ValidateVehicleFields(newVehicle); err != nil {
-			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-			return
-		}
-
-		if err := c.sv.ValidateDateFormat(newVehicle.Attributes.Registration); err != nil {
+		if err := c.sv.ValidateVehicleFields(ctx.Request.Context(), newVehicle); err != nil {
 			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
 
-		if err := c.sv.ValidateUniqueRegistration(newVehicle.Attributes.Registration); err != nil {
+		if err := c.sv.ValidateDateFormat(ctx.Request.Context(), newVehicle.Attributes.Registration); err != nil {
 			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
 
-		if err := c.sv.AddVehicle(newVehicle); err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add vehicle"})
+		// the uniqueness of the registration is checked by the service in the same transaction as the insert
+		vehicle, err := c.sv.AddVehicle(ctx.Request.Context(), newVehicle)
+		if err != nil {
+			switch {
+			case errors.Is(err, internal.ErrServiceVehicleRegistrationExists):
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "registration must be unique"})
+			default:
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add vehicle"})
+			}
 			return
 		}
+		newVehicle.ID = vehicle.ID
 
 		ctx.IndentedJSON(http.StatusCreated, newVehicle)
 	}
@@ -118,14 +127,10 @@ func (c *VehicleDefault) AddVehicle() gin.HandlerFunc {
 
 func (c *VehicleDefault) FindByColorAndYear() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		color := ctx.Param("color")
-		year, err := strconv.Atoi(ctx.Param("year"))
-		if err != nil {
-			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid year"})
-			return
-		}
+		color := middleware.ParamString(ctx, "color")
+		year := middleware.ParamInt(ctx, "year")
 
-		vehicles, err := c.sv.FindByColorAndYear(color, year)
+		vehicles, err := c.sv.FindByColorAndYear(ctx.Request.Context(), color, year)
 		if err != nil {
 			switch {
 			case errors.Is(err, internal.ErrServiceVehicleNotFound):
@@ -143,19 +148,11 @@ func (c *VehicleDefault) FindByColorAndYear() gin.HandlerFunc {
 
 func (c *VehicleDefault) FindByBrandAndYearRange() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
+		brand := middleware.ParamString(ctx, "brand")
+		startYear := middleware.ParamInt(ctx, "start_year")
+		endYear := middleware.ParamInt(ctx, "end_year")
 
-		brand := ctx.Param("brand")
-		startYear, err := strconv.Atoi(ctx.Param("start_year"))
-		if err != nil {
-			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_year"})
-			return
-		}
-		endYear, err := strconv.Atoi(ctx.Param("end_year"))
-		if err != nil {
-			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_year"})
-			return
-		}
-		vehicles, err := c.sv.FindByBrandAndYearRange(brand, startYear, endYear)
+		vehicles, err := c.sv.FindByBrandAndYearRange(ctx.Request.Context(), brand, startYear, endYear)
 		if err != nil {
 			if errors.Is(err, internal.ErrServiceVehicleNotFound) {
 				ctx.JSON(http.StatusNotFound, gin.H{"message": "vehicles not found"})
@@ -172,9 +169,9 @@ func (c *VehicleDefault) FindByBrandAndYearRange() gin.HandlerFunc {
 
 func (c *VehicleDefault) GetAverageSpeedByBrand() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		brand := ctx.Param("brand")
+		brand := middleware.ParamString(ctx, "brand")
 
-		averageSpeed, err := c.sv.GetAverageSpeedByBrand(brand)
+		averageSpeed, err := c.sv.GetAverageSpeedByBrand(ctx.Request.Context(), brand)
 		if err != nil {
 			if errors.Is(err, internal.ErrRepositoryVehicleNotFound) {
 				ctx.JSON(http.StatusNotFound, gin.H{"message": "vehicles not found for the brand"})
@@ -197,7 +194,7 @@ func (c *VehicleDefault) AddMultipleVehicles() gin.HandlerFunc {
 			return
 		}
 
-		if err := c.sv.AddMultipleVehicles(newVehicles); err != nil {
+		if _, err := c.sv.AddMultipleVehicles(ctx.Request.Context(), newVehicles); err != nil {
 			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
@@ -208,21 +205,10 @@ func (c *VehicleDefault) AddMultipleVehicles() gin.HandlerFunc {
 
 func (c *VehicleDefault) UpdateMaxSpeed() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		id := ctx.Param("id")
-		newMaxSpeed := ctx.PostForm("new_max_speed")
-
-		vehicleID, err := strconv.Atoi(id)
-		if err != nil {
-			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid vehicle ID"})
-			return
-		}
+		vehicleID := middleware.ParamInt(ctx, "id")
+		maxSpeed := middleware.ParamInt(ctx, "new_max_speed")
 
-		maxSpeed, err := strconv.Atoi(newMaxSpeed)
-		if err != nil {
-			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid maximum speed"})
-			return
-		}
-		if err := c.sv.UpdateMaxSpeed(vehicleID, maxSpeed); err != nil {
+		if err := c.sv.UpdateMaxSpeed(ctx.Request.Context(), vehicleID, maxSpeed); err != nil {
 			if errors.Is(err, internal.ErrRepositoryVehicleNotFound) {
 				ctx.JSON(http.StatusNotFound, gin.H{"error": "Vehicle not found"})
 				return
@@ -237,9 +223,9 @@ func (c *VehicleDefault) UpdateMaxSpeed() gin.HandlerFunc {
 
 func (c *VehicleDefault) GetByFuelType() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		fuelType := ctx.Param("type")
+		fuelType := middleware.ParamString(ctx, "type")
 
-		vehicles, err := c.sv.FindByFuelType(fuelType)
+		vehicles, err := c.sv.FindByFuelType(ctx.Request.Context(), fuelType)
 		if err != nil {
 			switch {
 			case errors.Is(err, internal.ErrServiceVehicleNotFound):
@@ -257,15 +243,9 @@ func (c *VehicleDefault) GetByFuelType() gin.HandlerFunc {
 
 func (c *VehicleDefault) DeleteVehicle() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		id := ctx.Param("id")
-
-		vehicleID, err := strconv.Atoi(id)
-		if err != nil {
-			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid vehicle ID"})
-			return
-		}
+		vehicleID := middleware.ParamInt(ctx, "id")
 
-		if err := c.sv.DeleteVehicleByID(vehicleID); err != nil {
+		if err := c.sv.DeleteVehicleByID(ctx.Request.Context(), vehicleID); err != nil {
 			switch {
 			case errors.Is(err, internal.ErrRepositoryVehicleNotFound):
 				ctx.JSON(http.StatusNotFound, gin.H{"error": "Vehicle not found"})
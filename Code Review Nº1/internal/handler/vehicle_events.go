@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"Code_Review_N_1/internal"
+	"Code_Review_N_1/internal/middleware"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// VehicleEventJSON is an struct that represents a vehicle event in json format.
+// - resets have no vehicle
+type VehicleEventJSON struct {
+	ID      uint64       `json:"id"`
+	Type    string       `json:"type"`
+	Time    time.Time    `json:"time"`
+	Vehicle *VehicleJSON `json:"vehicle,omitempty"`
+}
+
+// NewVehicleEvents returns a new instance of a vehicle events handler.
+// - heartbeat is the interval of the keep alive messages sent to idle clients
+func NewVehicleEvents(bs internal.VehicleEventBus, nz internal.VehicleNormalizer, heartbeat time.Duration) *VehicleEvents {
+	return &VehicleEvents{
+		bs:        bs,
+		nz:        nz,
+		heartbeat: heartbeat,
+		upgrader:  websocket.Upgrader{},
+	}
+}
+
+// VehicleEvents is an struct that contains handlers for the vehicle change feed.
+// - query parameters brand and fuel_type filter the events, compared by their normalized form
+// - Last-Event-ID header (or last_event_id query parameter) resumes a feed from the replay buffer,
+// or gets a vehicle.reset event when the missed events are no longer buffered, so the client refetches the vehicles
+// - clients that do not keep up are disconnected and must resume with the last received id
+type VehicleEvents struct {
+	bs        internal.VehicleEventBus
+	nz        internal.VehicleNormalizer
+	heartbeat time.Duration
+	upgrader  websocket.Upgrader
+}
+
+// subscribe subscribes to the bus with the filters and the resume point of the request.
+func (h *VehicleEvents) subscribe(ctx *gin.Context) (events <-chan internal.VehicleEvent, cancel func()) {
+	// filters
+	brand, byBrand := h.nz.Brand(middleware.ParamString(ctx, "brand")), middleware.HasParam(ctx, "brand")
+	fuelType, byFuelType := h.nz.FuelType(middleware.ParamString(ctx, "fuel_type")), middleware.HasParam(ctx, "fuel_type")
+	filter := func(e internal.VehicleEvent) bool {
+		return (!byBrand || e.Vehicle.Normalized.Brand == brand) &&
+			(!byFuelType || e.Vehicle.Normalized.FuelType == fuelType)
+	}
+
+	// resume point
+	var lastID uint64
+	if middleware.HasParam(ctx, "last_event_id") {
+		lastID = uint64(middleware.ParamInt(ctx, "last_event_id"))
+	}
+	if id, err := strconv.ParseUint(ctx.GetHeader("Last-Event-ID"), 10, 64); err == nil {
+		lastID = id
+	}
+
+	return h.bs.Subscribe(lastID, filter)
+}
+
+func convertEventToJSON(e internal.VehicleEvent) VehicleEventJSON {
+	ej := VehicleEventJSON{
+		ID:   e.ID,
+		Type: e.Type,
+		Time: e.Time,
+	}
+	if e.Type != internal.VehicleEventReset {
+		vj := convertVehicleToJSON(e.Vehicle)
+		ej.Vehicle = &vj
+	}
+	return ej
+}
+
+// SSE streams the vehicle events as Server-Sent Events.
+func (h *VehicleEvents) SSE() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// request
+		events, cancel := h.subscribe(ctx)
+		defer cancel()
+
+		// response
+		ctx.Header("Content-Type", "text/event-stream")
+		ctx.Header("Cache-Control", "no-cache")
+		ctx.Header("Connection", "keep-alive")
+		ctx.Header("X-Accel-Buffering", "no")
+		ctx.Status(http.StatusOK)
+		fmt.Fprintf(ctx.Writer, "retry: %d\n\n", h.heartbeat.Milliseconds())
+		ctx.Writer.Flush()
+
+		ticker := time.NewTicker(h.heartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Request.Context().Done():
+				return
+			case e, ok := <-events:
+				if !ok {
+					// dropped by the bus, the client reconnects with Last-Event-ID
+					return
+				}
+				data, err := json.Marshal(convertEventToJSON(e))
+				if err != nil {
+					return
+				}
+				if _, err := fmt.Fprintf(ctx.Writer, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data); err != nil {
+					return
+				}
+				ctx.Writer.Flush()
+			case <-ticker.C:
+				if _, err := fmt.Fprint(ctx.Writer, ": heartbeat\n\n"); err != nil {
+					return
+				}
+				ctx.Writer.Flush()
+			}
+		}
+	}
+}
+
+// WebSocket streams the vehicle events as json messages over a WebSocket.
+func (h *VehicleEvents) WebSocket() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// request
+		conn, err := h.upgrader.Upgrade(ctx.Writer, ctx.Request, nil)
+		if err != nil {
+			// the upgrader already replied with an error
+			return
+		}
+		defer conn.Close()
+		events, cancel := h.subscribe(ctx)
+		defer cancel()
+
+		// read until the client goes away, answering its control messages
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+
+		// response
+		ticker := time.NewTicker(h.heartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-closed:
+				return
+			case e, ok := <-events:
+				if !ok {
+					// dropped by the bus, the client reconnects with last_event_id
+					msg := websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "too slow, resume with last_event_id")
+					conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(h.heartbeat))
+					return
+				}
+				conn.SetWriteDeadline(time.Now().Add(h.heartbeat))
+				if err := conn.WriteJSON(convertEventToJSON(e)); err != nil {
+					return
+				}
+			case <-ticker.C:
+				if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(h.heartbeat)); err != nil {
+					return
+				}
+			}
+		}
+	}
+}
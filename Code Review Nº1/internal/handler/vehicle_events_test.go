@@ -0,0 +1,180 @@
+package handler_test
+
+import (
+	"Code_Review_N_1/docs"
+	"Code_Review_N_1/internal"
+	"Code_Review_N_1/internal/bus"
+	"Code_Review_N_1/internal/handler"
+	"Code_Review_N_1/internal/middleware"
+	"Code_Review_N_1/internal/normalize"
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// newEventsServer returns a server of the change feed of a bus that keeps the last replay events.
+func newEventsServer(t *testing.T, replay int) (*httptest.Server, *bus.VehicleMemory) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	vd, err := middleware.NewValidator(docs.OpenAPI)
+	if err != nil {
+		t.Fatalf("NewValidator() error = %v", err)
+	}
+	bs := bus.NewVehicleMemory(replay, 16)
+	he := handler.NewVehicleEvents(bs, normalize.NewVehicleSynonyms(normalize.DefaultSynonyms()), time.Hour)
+	rt := gin.New()
+	gr := rt.Group("/vehicles/events")
+	gr.Use(vd.Handler())
+	gr.GET("", he.SSE())
+	gr.GET("/ws", he.WebSocket())
+
+	srv := httptest.NewServer(rt)
+	t.Cleanup(srv.Close)
+	return srv, bs
+}
+
+// publish publishes the creation of a vehicle of every brand, with ids from 1.
+func publish(bs *bus.VehicleMemory, brands ...string) {
+	for i, brand := range brands {
+		a := internal.VehicleAttributes{Brand: brand}
+		bs.Publish(internal.VehicleEvent{Type: internal.VehicleEventCreated, Vehicle: internal.Vehicle{ID: i + 1, Attributes: a,
+			Normalized: internal.VehicleCategories{Brand: brand}}})
+	}
+}
+
+// sseEvent is an struct that represents an event read from a Server-Sent Events stream.
+type sseEvent struct {
+	id, event string
+	data      handler.VehicleEventJSON
+}
+
+func TestVehicleEvents_SSE(t *testing.T) {
+	tests := []struct {
+		name        string
+		query       string
+		lastEventID string
+		// want are the ids and names of the events read
+		want []string
+	}{
+		{name: "resume from the replay buffer", lastEventID: "2", want: []string{"3 vehicle.created", "4 vehicle.created"}},
+		{name: "resume with filter", query: "?brand=ford", lastEventID: "2", want: []string{"3 vehicle.created"}},
+		{name: "reset when the missed events are not buffered", lastEventID: "1", want: []string{"4 vehicle.reset"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, bs := newEventsServer(t, 2)
+			publish(bs, "Fiat", "Fiat", "Ford", "Fiat")
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/vehicles/events"+tt.query, nil)
+			req.Header.Set("Last-Event-ID", tt.lastEventID)
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("GET error = %v", err)
+			}
+			defer res.Body.Close()
+			if ct := res.Header.Get("Content-Type"); res.StatusCode != http.StatusOK || ct != "text/event-stream" {
+				t.Fatalf("response = %d %q, want %d text/event-stream", res.StatusCode, ct, http.StatusOK)
+			}
+
+			// read the events
+			var got []sseEvent
+			var e sseEvent
+			sc := bufio.NewScanner(res.Body)
+			for len(got) < len(tt.want) && sc.Scan() {
+				field, value, _ := strings.Cut(sc.Text(), ": ")
+				switch field {
+				case "id":
+					e.id = value
+				case "event":
+					e.event = value
+				case "data":
+					if err := json.Unmarshal([]byte(value), &e.data); err != nil {
+						t.Fatalf("data %q error = %v", value, err)
+					}
+				case "":
+					if e.id != "" {
+						got = append(got, e)
+					}
+					e = sseEvent{}
+				}
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d events, want %d", len(got), len(tt.want))
+			}
+			for i, e := range got {
+				if e.id+" "+e.event != tt.want[i] || e.data.Type != e.event {
+					t.Fatalf("event %d = %+v, want %s", i, e, tt.want[i])
+				}
+				if (e.data.Vehicle == nil) != (e.event == internal.VehicleEventReset) {
+					t.Fatalf("event %d vehicle = %+v, want a vehicle unless reset", i, e.data.Vehicle)
+				}
+			}
+		})
+	}
+}
+
+func TestVehicleEvents_WebSocket(t *testing.T) {
+	srv, bs := newEventsServer(t, 2)
+	publish(bs, "Fiat", "Fiat", "Ford", "Fiat")
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/vehicles/events/ws"
+
+	read := func(conn *websocket.Conn) (raw map[string]any, e handler.VehicleEventJSON) {
+		t.Helper()
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		_, b, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("ReadMessage() error = %v", err)
+		}
+		if err := json.Unmarshal(b, &raw); err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(b, &e); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	t.Run("reset when the missed events are not buffered", func(t *testing.T) {
+		conn, _, err := websocket.DefaultDialer.Dial(url+"?last_event_id=1", nil)
+		if err != nil {
+			t.Fatalf("Dial() error = %v", err)
+		}
+		defer conn.Close()
+
+		raw, e := read(conn)
+		if _, ok := raw["vehicle"]; ok || e.ID != 4 || e.Type != internal.VehicleEventReset {
+			t.Fatalf("message = %v, want a reset to id 4 without vehicle", raw)
+		}
+	})
+
+	t.Run("replay and live events with filter", func(t *testing.T) {
+		conn, _, err := websocket.DefaultDialer.Dial(url+"?brand=FORD&last_event_id=2", nil)
+		if err != nil {
+			t.Fatalf("Dial() error = %v", err)
+		}
+		defer conn.Close()
+
+		if _, e := read(conn); e.ID != 3 || e.Vehicle == nil || e.Vehicle.Brand != "Ford" {
+			t.Fatalf("replayed message = %+v, want event 3 of a Ford", e)
+		}
+		bs.Publish(internal.VehicleEvent{Type: internal.VehicleEventDeleted, Vehicle: internal.Vehicle{ID: 9,
+			Attributes: internal.VehicleAttributes{Brand: "Fiat"}, Normalized: internal.VehicleCategories{Brand: "Fiat"}}})
+		bs.Publish(internal.VehicleEvent{Type: internal.VehicleEventDeleted, Vehicle: internal.Vehicle{ID: 3,
+			Attributes: internal.VehicleAttributes{Brand: "Ford"}, Normalized: internal.VehicleCategories{Brand: "Ford"}}})
+		if _, e := read(conn); e.ID != 6 || e.Type != internal.VehicleEventDeleted {
+			t.Fatalf("live message = %+v, want the deletion 6 of a Ford", e)
+		}
+	})
+}
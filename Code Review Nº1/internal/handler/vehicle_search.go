@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"Code_Review_N_1/internal"
+	"Code_Review_N_1/internal/middleware"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// searchLimit is the number of results returned when no limit is requested.
+	searchLimit = 20
+	// searchMaxLimit is the maximum number of results of a search.
+	searchMaxLimit = 100
+)
+
+// VehicleMatchJSON is an struct that represents a vehicle found by a search in json format.
+type VehicleMatchJSON struct {
+	VehicleJSON
+	Score float64 `json:"score"`
+}
+
+// NewVehicleSearch returns a new instance of a vehicle search handler.
+func NewVehicleSearch(sv internal.ServiceVehicleSearch) *VehicleSearch {
+	return &VehicleSearch{sv: sv}
+}
+
+// VehicleSearch is an struct that contains handlers for the vehicle search.
+// - query parameters are validated and parsed by the validator middleware
+type VehicleSearch struct {
+	sv internal.ServiceVehicleSearch
+}
+
+// Search returns the vehicles matching the q query parameter, best first.
+func (c *VehicleSearch) Search() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// request
+		query := middleware.ParamString(ctx, "q")
+		limit := searchLimit
+		if middleware.HasParam(ctx, "limit") {
+			limit = min(max(middleware.ParamInt(ctx, "limit"), 1), searchMaxLimit)
+		}
+
+		// process
+		matches, err := c.sv.Search(ctx.Request.Context(), query, limit)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": "internal server error"})
+			return
+		}
+
+		// response
+		data := make([]VehicleMatchJSON, len(matches))
+		for i, m := range matches {
+			data[i] = VehicleMatchJSON{VehicleJSON: convertVehicleToJSON(m.Vehicle), Score: m.Score}
+		}
+		ctx.JSON(http.StatusOK, gin.H{"message": "success to search vehicles", "data": data})
+	}
+}
@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"Code_Review_N_1/internal"
+	"Code_Review_N_1/internal/middleware"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// similarLimit is the number of similar vehicles returned when no k is requested.
+	similarLimit = 10
+	// similarMaxLimit is the maximum number of similar vehicles.
+	similarMaxLimit = 100
+)
+
+// VehicleSimilarJSON is an struct that represents a similar vehicle in json format.
+type VehicleSimilarJSON struct {
+	VehicleJSON
+	Distance float64 `json:"distance"`
+}
+
+// VehicleCompareRequestJSON is an struct that represents the body of a vehicle comparison in json format.
+type VehicleCompareRequestJSON struct {
+	IDs []int `json:"ids"`
+}
+
+// VehicleFieldComparisonJSON is an struct that represents the values of a field in the compared vehicles in json format.
+type VehicleFieldComparisonJSON struct {
+	Field  string `json:"field"`
+	Values []any  `json:"values"`
+	Equal  bool   `json:"equal"`
+}
+
+// VehicleComparisonJSON is an struct that represents a side-by-side comparison of vehicles in json format.
+type VehicleComparisonJSON struct {
+	Vehicles []VehicleJSON                `json:"vehicles"`
+	Fields   []VehicleFieldComparisonJSON `json:"fields"`
+}
+
+// NewVehicleSimilarity returns a new instance of a vehicle similarity handler.
+func NewVehicleSimilarity(sv internal.ServiceVehicleSimilarity) *VehicleSimilarity {
+	return &VehicleSimilarity{sv: sv}
+}
+
+// VehicleSimilarity is an struct that contains handlers for the vehicle similarity and comparison.
+// - parameters and bodies are validated and parsed by the validator middleware
+type VehicleSimilarity struct {
+	sv internal.ServiceVehicleSimilarity
+}
+
+// Similar returns the k vehicles most similar to a vehicle, most similar first.
+func (c *VehicleSimilarity) Similar() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// request
+		id := middleware.ParamInt(ctx, "id")
+		k := similarLimit
+		if middleware.HasParam(ctx, "k") {
+			k = middleware.ParamInt(ctx, "k")
+			if k <= 0 {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "k must be greater than 0"})
+				return
+			}
+			k = min(k, similarMaxLimit)
+		}
+
+		// process
+		similar, err := c.sv.FindSimilar(ctx.Request.Context(), id, k)
+		if err != nil {
+			switch {
+			case errors.Is(err, internal.ErrServiceVehicleNotFound):
+				ctx.JSON(http.StatusNotFound, gin.H{"message": "vehicle not found"})
+			default:
+				ctx.JSON(http.StatusInternalServerError, gin.H{"message": "internal server error"})
+			}
+			return
+		}
+
+		// response
+		data := make([]VehicleSimilarJSON, len(similar))
+		for i, s := range similar {
+			data[i] = VehicleSimilarJSON{VehicleJSON: convertVehicleToJSON(s.Vehicle), Distance: s.Distance}
+		}
+		ctx.JSON(http.StatusOK, gin.H{"message": "success to find similar vehicles", "data": data})
+	}
+}
+
+// Compare returns a side-by-side comparison of the vehicles of the body.
+func (c *VehicleSimilarity) Compare() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// request
+		var body VehicleCompareRequestJSON
+		if err := ctx.ShouldBindJSON(&body); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
+			return
+		}
+
+		// process
+		comparison, err := c.sv.Compare(ctx.Request.Context(), body.IDs)
+		if err != nil {
+			switch {
+			case errors.Is(err, internal.ErrServiceVehicleCompareInvalid):
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			case errors.Is(err, internal.ErrServiceVehicleNotFound):
+				ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			default:
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compare vehicles"})
+			}
+			return
+		}
+
+		// response
+		data := VehicleComparisonJSON{
+			Vehicles: convertVehiclesToJSON(comparison.Vehicles),
+			Fields:   make([]VehicleFieldComparisonJSON, len(comparison.Fields)),
+		}
+		for i, f := range comparison.Fields {
+			data.Fields[i] = VehicleFieldComparisonJSON{Field: f.Field, Values: f.Values, Equal: f.Equal}
+		}
+		ctx.JSON(http.StatusOK, gin.H{"message": "success to compare vehicles", "data": data})
+	}
+}
@@ -0,0 +1,372 @@
+package handler
+
+import (
+	"Code_Review_N_1/internal"
+	"Code_Review_N_1/internal/middleware"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ResponseV2 is an struct that represents the envelope of every v2 response.
+type ResponseV2 struct {
+	// Data is the payload of a successful response.
+	Data any `json:"data,omitempty"`
+	// Meta is the metadata of a collection response.
+	Meta *MetaV2 `json:"meta,omitempty"`
+	// Error is the error of a failed response.
+	Error *ErrorV2 `json:"error,omitempty"`
+}
+
+// MetaV2 is an struct that represents the metadata of a v2 collection response.
+type MetaV2 struct {
+	// Count is the number of items in data.
+	Count int `json:"count"`
+}
+
+// ErrorV2 is an struct that represents the error of a v2 response.
+type ErrorV2 struct {
+	// Code is a machine readable identifier of the error.
+	Code string `json:"code"`
+	// Message is a human readable description of the error.
+	Message string `json:"message"`
+	// Violations are the rules of the api document broken by the request.
+	Violations []middleware.Violation `json:"violations,omitempty"`
+}
+
+// VehicleMetricsJSON is an struct that represents the derived metrics of a vehicle in json format.
+type VehicleMetricsJSON struct {
+	Footprint          float64 `json:"footprint"`
+	WeightPerPassenger float64 `json:"weight_per_passenger"`
+	SizeClass          string  `json:"size_class"`
+}
+
+// VehicleV2JSON is an struct that represents a vehicle with its derived metrics in json format.
+type VehicleV2JSON struct {
+	VehicleJSON
+	Metrics VehicleMetricsJSON `json:"metrics"`
+}
+
+// MaxSpeedPatchJSON is an struct that represents the body of a vehicle patch in json format.
+type MaxSpeedPatchJSON struct {
+	MaxSpeed int `json:"max_speed"`
+}
+
+// NewVehicleV2 returns a new instance of a v2 vehicle handler.
+func NewVehicleV2(sv internal.ServiceVehicle) *VehicleV2 {
+	return &VehicleV2{sv: sv}
+}
+
+// VehicleV2 is an struct that contains the v2 handlers for vehicle.
+// - path and query parameters are validated and parsed by the validator middleware
+// - empty collections are returned as 200 with no items instead of 404
+type VehicleV2 struct {
+	sv internal.ServiceVehicle
+}
+
+// ViolationsV2 renders a request rejected by the validator middleware with the v2 envelope.
+func ViolationsV2(ctx *gin.Context, violations []middleware.Violation) {
+	ctx.JSON(http.StatusBadRequest, ResponseV2{Error: &ErrorV2{Code: "invalid_request", Message: "invalid request", Violations: violations}})
+}
+
+// errorV2 writes a v2 error response.
+func errorV2(ctx *gin.Context, code int, errCode, message string) {
+	ctx.JSON(code, ResponseV2{Error: &ErrorV2{Code: errCode, Message: message}})
+}
+
+func convertVehiclesToV2JSON(vehicles []internal.Vehicle) []VehicleV2JSON {
+	data := make([]VehicleV2JSON, len(vehicles))
+	for i, vehicle := range vehicles {
+		data[i] = VehicleV2JSON{
+			VehicleJSON: convertVehicleToJSON(vehicle),
+			Metrics: VehicleMetricsJSON{
+				Footprint:          vehicle.Metrics.Footprint,
+				WeightPerPassenger: vehicle.Metrics.WeightPerPassenger,
+				SizeClass:          vehicle.Metrics.SizeClass,
+			},
+		}
+	}
+	return data
+}
+
+func convertJSONToVehicle(vehicle VehicleJSON) internal.Vehicle {
+	return internal.Vehicle{
+		ID: vehicle.ID,
+		Attributes: internal.VehicleAttributes{
+			Brand:        vehicle.Brand,
+			Model:        vehicle.Model,
+			Registration: vehicle.Registration,
+			Year:         vehicle.Year,
+			Color:        vehicle.Color,
+			MaxSpeed:     vehicle.MaxSpeed,
+			FuelType:     vehicle.FuelType,
+			Transmission: vehicle.Transmission,
+			Passengers:   vehicle.Passengers,
+			Height:       vehicle.Height,
+			Width:        vehicle.Width,
+			Weight:       vehicle.Weight,
+		},
+	}
+}
+
+// List returns the vehicles matching the query filters.
+// - fuel_type
+// - color and year
+// - brand, optionally with year_from and year_to
+// - any of them refined by the derived metrics: size_class, footprint_min/max, weight_per_passenger_min/max
+// and ordered by sort (footprint or weight_per_passenger, with a leading - for descending order)
+func (c *VehicleV2) List() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// request
+		byFuelType := middleware.HasParam(ctx, "fuel_type")
+		byColor := middleware.HasParam(ctx, "color") || middleware.HasParam(ctx, "year")
+		byBrand := middleware.HasParam(ctx, "brand") || middleware.HasParam(ctx, "year_from") || middleware.HasParam(ctx, "year_to")
+		q := internal.VehicleMetricsQuery{
+			SizeClass:             middleware.ParamString(ctx, "size_class"),
+			FootprintMin:          middleware.ParamFloat(ctx, "footprint_min"),
+			FootprintMax:          middleware.ParamFloat(ctx, "footprint_max"),
+			WeightPerPassengerMin: middleware.ParamFloat(ctx, "weight_per_passenger_min"),
+			WeightPerPassengerMax: middleware.ParamFloat(ctx, "weight_per_passenger_max"),
+		}
+		q.SortBy, q.Descending = strings.CutPrefix(middleware.ParamString(ctx, "sort"), "-")
+
+		// process
+		var vehicles []internal.Vehicle
+		var err error
+		switch {
+		case !byFuelType && !byColor && !byBrand:
+			vehicles, err = c.sv.FindAll(ctx.Request.Context())
+		case byFuelType && !byColor && !byBrand:
+			vehicles, err = c.sv.FindByFuelType(ctx.Request.Context(), middleware.ParamString(ctx, "fuel_type"))
+		case byColor && !byFuelType && !byBrand:
+			if !middleware.HasParam(ctx, "color") || !middleware.HasParam(ctx, "year") {
+				errorV2(ctx, http.StatusBadRequest, "invalid_filter", "color and year must be used together")
+				return
+			}
+			vehicles, err = c.sv.FindByColorAndYear(ctx.Request.Context(), middleware.ParamString(ctx, "color"), middleware.ParamInt(ctx, "year"))
+		case byBrand && !byFuelType && !byColor:
+			if !middleware.HasParam(ctx, "brand") {
+				errorV2(ctx, http.StatusBadRequest, "invalid_filter", "year_from and year_to require brand")
+				return
+			}
+			yearFrom, yearTo := 0, math.MaxInt
+			if middleware.HasParam(ctx, "year_from") {
+				yearFrom = middleware.ParamInt(ctx, "year_from")
+			}
+			if middleware.HasParam(ctx, "year_to") {
+				yearTo = middleware.ParamInt(ctx, "year_to")
+			}
+			vehicles, err = c.sv.FindByBrandAndYearRange(ctx.Request.Context(), middleware.ParamString(ctx, "brand"), yearFrom, yearTo)
+		default:
+			errorV2(ctx, http.StatusBadRequest, "invalid_filter", "fuel_type, color/year and brand filters cannot be combined")
+			return
+		}
+		if err != nil && !errors.Is(err, internal.ErrServiceVehicleNotFound) {
+			errorV2(ctx, http.StatusInternalServerError, "internal", "internal server error")
+			return
+		}
+		vehicles, err = c.sv.FilterByMetrics(ctx.Request.Context(), vehicles, q)
+		if err != nil {
+			switch {
+			case errors.Is(err, internal.ErrServiceVehicleMetricsQueryInvalid):
+				errorV2(ctx, http.StatusBadRequest, "invalid_filter", err.Error())
+			default:
+				errorV2(ctx, http.StatusInternalServerError, "internal", "internal server error")
+			}
+			return
+		}
+
+		// response
+		data := convertVehiclesToV2JSON(vehicles)
+		ctx.JSON(http.StatusOK, ResponseV2{Data: data, Meta: &MetaV2{Count: len(data)}})
+	}
+}
+
+// AverageSpeed returns the average maximum speed of a brand.
+func (c *VehicleV2) AverageSpeed() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// request
+		brand := middleware.ParamString(ctx, "brand")
+
+		// process
+		averageSpeed, err := c.sv.GetAverageSpeedByBrand(ctx.Request.Context(), brand)
+		if err != nil {
+			switch {
+			case errors.Is(err, internal.ErrRepositoryVehicleNotFound), errors.Is(err, internal.ErrServiceVehicleNotFound):
+				errorV2(ctx, http.StatusNotFound, "not_found", "no vehicles found for the brand")
+			default:
+				errorV2(ctx, http.StatusInternalServerError, "internal", "internal server error")
+			}
+			return
+		}
+
+		// response
+		ctx.JSON(http.StatusOK, ResponseV2{Data: gin.H{"brand": brand, "average_speed": averageSpeed}})
+	}
+}
+
+// Get returns a vehicle.
+func (c *VehicleV2) Get() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// request
+		id := middleware.ParamInt(ctx, "id")
+
+		// process
+		vehicle, err := c.sv.FindByID(ctx.Request.Context(), id)
+		if err != nil {
+			switch {
+			case errors.Is(err, internal.ErrServiceVehicleNotFound):
+				errorV2(ctx, http.StatusNotFound, "not_found", "vehicle not found")
+			default:
+				errorV2(ctx, http.StatusInternalServerError, "internal", "internal server error")
+			}
+			return
+		}
+
+		// response
+		ctx.JSON(http.StatusOK, ResponseV2{Data: convertVehiclesToV2JSON([]internal.Vehicle{vehicle})[0]})
+	}
+}
+
+// vehicleLocation returns the url of a vehicle of the v2 api.
+func vehicleLocation(id int) string {
+	return "/v2/vehicles/" + strconv.Itoa(id)
+}
+
+// validateVehicle checks the fields of a vehicle to be created.
+func (c *VehicleV2) validateVehicle(ctx *gin.Context, vehicle internal.Vehicle) error {
+	if err := c.sv.ValidateVehicleFields(ctx.Request.Context(), vehicle); err != nil {
+		return err
+	}
+	return c.sv.ValidateDateFormat(ctx.Request.Context(), vehicle.Attributes.Registration)
+}
+
+// Create adds a vehicle.
+// - the id is assigned by the server, the one of the body is ignored
+// - the created vehicle is returned like Get, with its derived metrics
+func (c *VehicleV2) Create() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// request
+		var body VehicleJSON
+		if err := ctx.ShouldBindJSON(&body); err != nil {
+			errorV2(ctx, http.StatusBadRequest, "invalid_request", "invalid json")
+			return
+		}
+		body.ID = 0
+		vehicle := convertJSONToVehicle(body)
+
+		// process
+		if err := c.validateVehicle(ctx, vehicle); err != nil {
+			errorV2(ctx, http.StatusBadRequest, "invalid_vehicle", err.Error())
+			return
+		}
+		vehicle, err := c.sv.AddVehicle(ctx.Request.Context(), vehicle)
+		if err != nil {
+			switch {
+			case errors.Is(err, internal.ErrServiceVehicleRegistrationExists):
+				errorV2(ctx, http.StatusConflict, "conflict", "registration must be unique")
+			default:
+				errorV2(ctx, http.StatusInternalServerError, "internal", "failed to add vehicle")
+			}
+			return
+		}
+
+		// response
+		ctx.Header("Location", vehicleLocation(vehicle.ID))
+		ctx.JSON(http.StatusCreated, ResponseV2{Data: convertVehiclesToV2JSON([]internal.Vehicle{vehicle})[0]})
+	}
+}
+
+// CreateBatch adds several vehicles, all of them or none.
+// - the ids are assigned by the server, the ones of the body are ignored
+func (c *VehicleV2) CreateBatch() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// request
+		var body []VehicleJSON
+		if err := ctx.ShouldBindJSON(&body); err != nil {
+			errorV2(ctx, http.StatusBadRequest, "invalid_request", "invalid json")
+			return
+		}
+		vehicles := make([]internal.Vehicle, len(body))
+		for i, v := range body {
+			v.ID = 0
+			vehicles[i] = convertJSONToVehicle(v)
+		}
+
+		// process
+		for i, vehicle := range vehicles {
+			if err := c.validateVehicle(ctx, vehicle); err != nil {
+				errorV2(ctx, http.StatusBadRequest, "invalid_vehicle", fmt.Sprintf("vehicle %d: %v", i, err))
+				return
+			}
+		}
+		vehicles, err := c.sv.AddMultipleVehicles(ctx.Request.Context(), vehicles)
+		if err != nil {
+			switch {
+			case errors.Is(err, internal.ErrServiceVehicleRegistrationExists):
+				errorV2(ctx, http.StatusConflict, "conflict", err.Error())
+			default:
+				errorV2(ctx, http.StatusInternalServerError, "internal", "failed to add vehicles")
+			}
+			return
+		}
+
+		// response
+		data := convertVehiclesToV2JSON(vehicles)
+		ctx.JSON(http.StatusCreated, ResponseV2{Data: data, Meta: &MetaV2{Count: len(data)}})
+	}
+}
+
+// Patch updates the maximum speed of a vehicle.
+func (c *VehicleV2) Patch() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// request
+		id := middleware.ParamInt(ctx, "id")
+		var body MaxSpeedPatchJSON
+		if err := ctx.ShouldBindJSON(&body); err != nil {
+			errorV2(ctx, http.StatusBadRequest, "invalid_request", "invalid json")
+			return
+		}
+
+		// process
+		if err := c.sv.UpdateMaxSpeed(ctx.Request.Context(), id, body.MaxSpeed); err != nil {
+			switch {
+			case errors.Is(err, internal.ErrRepositoryVehicleNotFound):
+				errorV2(ctx, http.StatusNotFound, "not_found", "vehicle not found")
+			default:
+				errorV2(ctx, http.StatusInternalServerError, "internal", "failed to update vehicle")
+			}
+			return
+		}
+
+		// response
+		ctx.JSON(http.StatusOK, ResponseV2{Data: gin.H{"id": id, "max_speed": body.MaxSpeed}})
+	}
+}
+
+// Delete removes a vehicle.
+func (c *VehicleV2) Delete() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// request
+		id := middleware.ParamInt(ctx, "id")
+
+		// process
+		if err := c.sv.DeleteVehicleByID(ctx.Request.Context(), id); err != nil {
+			switch {
+			case errors.Is(err, internal.ErrRepositoryVehicleNotFound):
+				errorV2(ctx, http.StatusNotFound, "not_found", "vehicle not found")
+			default:
+				errorV2(ctx, http.StatusInternalServerError, "internal", "failed to delete vehicle")
+			}
+			return
+		}
+
+		// response
+		ctx.Status(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,250 @@
+package handler
+
+import (
+	"Code_Review_N_1/internal"
+	"Code_Review_N_1/internal/middleware"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WebhookRequestJSON is an struct that represents the body of a webhook creation in json format.
+type WebhookRequestJSON struct {
+	URL    string   `json:"url"`
+	Secret string   `json:"secret"`
+	Events []string `json:"events"`
+}
+
+// WebhookJSON is an struct that represents a webhook in json format.
+// - the secret is only returned on creation
+type WebhookJSON struct {
+	ID        int       `json:"id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret,omitempty"`
+	Events    []string  `json:"events"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// WebhookAttemptJSON is an struct that represents a delivery attempt in json format.
+type WebhookAttemptJSON struct {
+	DeliveryID int       `json:"delivery_id"`
+	EventID    uint64    `json:"event_id"`
+	EventType  string    `json:"event_type"`
+	Attempt    int       `json:"attempt"`
+	StatusCode int       `json:"status_code"`
+	Error      string    `json:"error,omitempty"`
+	Time       time.Time `json:"time"`
+	DurationMs int64     `json:"duration_ms"`
+}
+
+// WebhookDeadLetterJSON is an struct that represents a dead letter in json format.
+type WebhookDeadLetterJSON struct {
+	ID         int              `json:"id"`
+	DeliveryID int              `json:"delivery_id"`
+	WebhookID  int              `json:"webhook_id"`
+	Event      VehicleEventJSON `json:"event"`
+	Attempts   int              `json:"attempts"`
+	LastError  string           `json:"last_error"`
+	Time       time.Time        `json:"time"`
+}
+
+// NewWebhookDefault returns a new instance of a webhook handler.
+func NewWebhookDefault(sv internal.ServiceWebhook) *WebhookDefault {
+	return &WebhookDefault{sv: sv}
+}
+
+// WebhookDefault is an struct that contains handlers for webhook.
+// - path parameters are validated and parsed by the validator middleware
+type WebhookDefault struct {
+	sv internal.ServiceWebhook
+}
+
+func convertWebhookToJSON(w internal.Webhook) WebhookJSON {
+	events := w.Events
+	if events == nil {
+		events = []string{}
+	}
+	return WebhookJSON{ID: w.ID, URL: w.URL, Events: events, CreatedAt: w.CreatedAt}
+}
+
+// Create adds a webhook.
+func (c *WebhookDefault) Create() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// request
+		var body WebhookRequestJSON
+		if err := ctx.ShouldBindJSON(&body); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
+			return
+		}
+
+		// process
+		w, err := c.sv.Create(ctx.Request.Context(), internal.Webhook{URL: body.URL, Secret: body.Secret, Events: body.Events})
+		if err != nil {
+			switch {
+			case errors.Is(err, internal.ErrServiceWebhookInvalid):
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			default:
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create webhook"})
+			}
+			return
+		}
+
+		// response
+		data := convertWebhookToJSON(w)
+		data.Secret = w.Secret
+		ctx.JSON(http.StatusCreated, gin.H{"message": "success to create webhook", "data": data})
+	}
+}
+
+// GetAll returns all webhooks.
+func (c *WebhookDefault) GetAll() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// process
+		webhooks, err := c.sv.FindAll(ctx.Request.Context())
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": "internal server error"})
+			return
+		}
+
+		// response
+		data := make([]WebhookJSON, len(webhooks))
+		for i, w := range webhooks {
+			data[i] = convertWebhookToJSON(w)
+		}
+		ctx.JSON(http.StatusOK, gin.H{"message": "success to find webhooks", "data": data})
+	}
+}
+
+// GetByID returns a webhook.
+func (c *WebhookDefault) GetByID() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// request
+		id := middleware.ParamInt(ctx, "id")
+
+		// process
+		w, err := c.sv.FindByID(ctx.Request.Context(), id)
+		if err != nil {
+			switch {
+			case errors.Is(err, internal.ErrServiceWebhookNotFound):
+				ctx.JSON(http.StatusNotFound, gin.H{"message": "webhook not found"})
+			default:
+				ctx.JSON(http.StatusInternalServerError, gin.H{"message": "internal server error"})
+			}
+			return
+		}
+
+		// response
+		ctx.JSON(http.StatusOK, gin.H{"message": "success to find webhook", "data": convertWebhookToJSON(w)})
+	}
+}
+
+// Delete removes a webhook.
+func (c *WebhookDefault) Delete() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// request
+		id := middleware.ParamInt(ctx, "id")
+
+		// process
+		if err := c.sv.DeleteByID(ctx.Request.Context(), id); err != nil {
+			switch {
+			case errors.Is(err, internal.ErrServiceWebhookNotFound):
+				ctx.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+			default:
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete webhook"})
+			}
+			return
+		}
+
+		// response
+		ctx.Status(http.StatusNoContent)
+	}
+}
+
+// GetDeliveries returns the delivery log of a webhook, newest first.
+func (c *WebhookDefault) GetDeliveries() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// request
+		id := middleware.ParamInt(ctx, "id")
+
+		// process
+		attempts, err := c.sv.FindAttempts(ctx.Request.Context(), id)
+		if err != nil {
+			switch {
+			case errors.Is(err, internal.ErrServiceWebhookNotFound):
+				ctx.JSON(http.StatusNotFound, gin.H{"message": "webhook not found"})
+			default:
+				ctx.JSON(http.StatusInternalServerError, gin.H{"message": "internal server error"})
+			}
+			return
+		}
+
+		// response
+		data := make([]WebhookAttemptJSON, len(attempts))
+		for i, a := range attempts {
+			data[i] = WebhookAttemptJSON{
+				DeliveryID: a.DeliveryID,
+				EventID:    a.EventID,
+				EventType:  a.EventType,
+				Attempt:    a.Attempt,
+				StatusCode: a.StatusCode,
+				Error:      a.Error,
+				Time:       a.Time,
+				DurationMs: a.Duration.Milliseconds(),
+			}
+		}
+		ctx.JSON(http.StatusOK, gin.H{"message": "success to find deliveries", "data": data})
+	}
+}
+
+// GetDeadLetters returns the deliveries that exhausted their retries.
+func (c *WebhookDefault) GetDeadLetters() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// process
+		letters, err := c.sv.FindDeadLetters(ctx.Request.Context())
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": "internal server error"})
+			return
+		}
+
+		// response
+		data := make([]WebhookDeadLetterJSON, len(letters))
+		for i, d := range letters {
+			data[i] = WebhookDeadLetterJSON{
+				ID:         d.ID,
+				DeliveryID: d.DeliveryID,
+				WebhookID:  d.WebhookID,
+				Event:      convertEventToJSON(d.Event),
+				Attempts:   d.Attempts,
+				LastError:  d.LastError,
+				Time:       d.Time,
+			}
+		}
+		ctx.JSON(http.StatusOK, gin.H{"message": "success to find dead letters", "data": data})
+	}
+}
+
+// RetryDeadLetter schedules a dead letter for delivery again.
+func (c *WebhookDefault) RetryDeadLetter() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// request
+		id := middleware.ParamInt(ctx, "id")
+
+		// process
+		if err := c.sv.RetryDeadLetter(ctx.Request.Context(), id); err != nil {
+			switch {
+			case errors.Is(err, internal.ErrServiceDeadLetterNotFound):
+				ctx.JSON(http.StatusNotFound, gin.H{"error": "Dead letter not found"})
+			case errors.Is(err, internal.ErrServiceWebhookNotFound):
+				ctx.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+			default:
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retry dead letter"})
+			}
+			return
+		}
+
+		// response
+		ctx.JSON(http.StatusAccepted, gin.H{"message": "Dead letter scheduled for delivery"})
+	}
+}
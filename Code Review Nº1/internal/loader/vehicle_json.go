@@ -27,13 +27,15 @@ type LoadDataJSON struct {
 }
 
 // NewVehicleJSON returns a new instance of a vehicle loader.
-func NewVehicleJSON(path string) *VehicleJSON {
-	return &VehicleJSON{Path: path}
+func NewVehicleJSON(path string, nz internal.VehicleNormalizer) *VehicleJSON {
+	return &VehicleJSON{Path: path, nz: nz}
 }
 
 // VehicleJSON is an struct that implements the LoaderVehicle interface.
+// - the categorical attributes of every vehicle are normalized
 type VehicleJSON struct {
 	Path string
+	nz   internal.VehicleNormalizer
 }
 
 // Load returns all vehicles.
@@ -73,6 +75,7 @@ func (l *VehicleJSON) Load() (d internal.LoadData, err error) {
 				Weight:       vehicle.Weight,
 			},
 		}
+		d.Data[i].Normalized = l.nz.Normalize(d.Data[i].Attributes)
 	}
 	// - last id
 	d.LastId = loadDataJSON.LastId
@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CacheControl returns a middleware that sets the Cache-Control header of the responses.
+// - maxAge is the time clients may reuse a response, zero forces them to revalidate
+func CacheControl(maxAge time.Duration) gin.HandlerFunc {
+	value := "no-cache"
+	if maxAge > 0 {
+		value = fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds()))
+	}
+
+	return func(ctx *gin.Context) {
+		ctx.Header("Cache-Control", value)
+		ctx.Next()
+	}
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Deprecation returns a middleware that flags the responses as deprecated.
+// - Deprecation (RFC 9745) is the date when the resource was deprecated
+// - Sunset (RFC 8594) is the date when the resource will stop responding
+// - Link points to the resource that replaces the deprecated one
+func Deprecation(deprecatedAt, sunset time.Time, successor string) gin.HandlerFunc {
+	deprecation := fmt.Sprintf("@%d", deprecatedAt.Unix())
+	sunsetAt := sunset.UTC().Format(http.TimeFormat)
+	link := fmt.Sprintf("<%s>; rel=\"successor-version\"", successor)
+
+	return func(ctx *gin.Context) {
+		ctx.Header("Deprecation", deprecation)
+		ctx.Header("Sunset", sunsetAt)
+		if successor != "" {
+			ctx.Header("Link", link)
+		}
+		ctx.Next()
+	}
+}
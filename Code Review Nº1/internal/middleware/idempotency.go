@@ -0,0 +1,214 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// HeaderIdempotencyKey is the header that identifies the retries of a request.
+	HeaderIdempotencyKey = "Idempotency-Key"
+	// HeaderIdempotentReplayed is the header set on the responses replayed from the store.
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+)
+
+// IdempotencyRecord is an struct that represents the outcome of the first request with an idempotency key.
+type IdempotencyRecord struct {
+	// Fingerprint is the hash of the body of the request.
+	Fingerprint string
+	// Done is false while the first request is being processed.
+	Done bool
+	// Status is the status code of the response.
+	Status int
+	// ContentType is the content type of the response.
+	ContentType string
+	// Body is the body of the response.
+	Body []byte
+}
+
+// IdempotencyStore is the interface that wraps the basic methods for a store of idempotency records.
+type IdempotencyStore interface {
+	// Reserve stores a pending record for key, or returns the record already stored and true
+	Reserve(key, fingerprint string, ttl time.Duration, now time.Time) (r IdempotencyRecord, found bool)
+	// Complete stores the response of the request that reserved key
+	Complete(key string, r IdempotencyRecord, ttl time.Duration, now time.Time)
+	// Release removes the record of key so the request can be retried
+	Release(key string)
+}
+
+// NewIdempotencyStoreMemory returns a new instance of an in-memory idempotency store.
+func NewIdempotencyStoreMemory() *IdempotencyStoreMemory {
+	return &IdempotencyStoreMemory{
+		records: make(map[string]idempotencyEntry),
+	}
+}
+
+// idempotencyEntry is an struct that represents a stored idempotency record.
+type idempotencyEntry struct {
+	// record is the stored record.
+	record IdempotencyRecord
+	// expiresAt is the time when the record is forgotten.
+	expiresAt time.Time
+}
+
+// IdempotencyStoreMemory is an struct that represents an in-memory store of idempotency records.
+// - expired records are evicted lazily
+type IdempotencyStoreMemory struct {
+	// mu guards records and lastSweep.
+	mu sync.Mutex
+	// records are the records by key.
+	records map[string]idempotencyEntry
+	// lastSweep is the last time expired records were evicted.
+	lastSweep time.Time
+}
+
+// Reserve stores a pending record for key, or returns the record already stored and true.
+func (s *IdempotencyStoreMemory) Reserve(key, fingerprint string, ttl time.Duration, now time.Time) (r IdempotencyRecord, found bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// evict expired records
+	if now.Sub(s.lastSweep) >= ttl {
+		for k, e := range s.records {
+			if !now.Before(e.expiresAt) {
+				delete(s.records, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	// lookup
+	if e, ok := s.records[key]; ok && now.Before(e.expiresAt) {
+		return e.record, true
+	}
+	s.records[key] = idempotencyEntry{record: IdempotencyRecord{Fingerprint: fingerprint}, expiresAt: now.Add(ttl)}
+	return
+}
+
+// Complete stores the response of the request that reserved key.
+func (s *IdempotencyStoreMemory) Complete(key string, r IdempotencyRecord, ttl time.Duration, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r.Done = true
+	s.records[key] = idempotencyEntry{record: r, expiresAt: now.Add(ttl)}
+}
+
+// Release removes the record of key so the request can be retried.
+func (s *IdempotencyStoreMemory) Release(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, key)
+}
+
+// NewIdempotency returns a new instance of an idempotency middleware.
+// - ttl is the time the responses are kept for replay
+// - apiKeys are the keys that identify a client, any other key is ignored
+func NewIdempotency(st IdempotencyStore, ttl time.Duration, apiKeys []string) *Idempotency {
+	keys := make(map[string]struct{}, len(apiKeys))
+	for _, key := range apiKeys {
+		keys[key] = struct{}{}
+	}
+	return &Idempotency{st: st, ttl: ttl, apiKeys: keys}
+}
+
+// Idempotency is an struct that replays the response of the requests retried with the same idempotency key.
+// - keys are scoped by client (api key, or ip when missing or unknown) and route handler, so the aliases of a route share them
+// - server errors are not stored, so the request can be retried
+type Idempotency struct {
+	// st is the store of records.
+	st IdempotencyStore
+	// ttl is the time the responses are kept for replay.
+	ttl time.Duration
+	// apiKeys are the known api keys.
+	apiKeys map[string]struct{}
+}
+
+// recorder is an struct that captures the body of a response while writing it.
+type recorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+// Write writes b to the response and the capture.
+func (r *recorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+// WriteString writes s to the response and the capture.
+func (r *recorder) WriteString(s string) (int, error) {
+	r.body.WriteString(s)
+	return r.ResponseWriter.WriteString(s)
+}
+
+// Handler returns the middleware that replays the stored response of a retried request.
+// - a key reused with a different body is rejected with 422
+// - a key whose first request is still in progress is rejected with 409
+func (i *Idempotency) Handler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// request
+		key := ctx.GetHeader(HeaderIdempotencyKey)
+		if key == "" {
+			ctx.Next()
+			return
+		}
+		b, err := io.ReadAll(ctx.Request.Body)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "could not read body"})
+			return
+		}
+		ctx.Request.Body = io.NopCloser(bytes.NewReader(b))
+		sum := sha256.Sum256(b)
+		fingerprint := hex.EncodeToString(sum[:])
+		client := clientID(ctx, i.apiKeys)
+		// - the handler, not the path, identifies the route: aliases register the same handler under other prefixes
+		key = client + " " + ctx.Request.Method + " " + ctx.HandlerName() + " " + key
+
+		// process
+		r, found := i.st.Reserve(key, fingerprint, i.ttl, time.Now())
+		switch {
+		case found && r.Fingerprint != fingerprint:
+			ctx.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": "idempotency key reused with a different request"})
+			return
+		case found && !r.Done:
+			ctx.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "a request with this idempotency key is in progress"})
+			return
+		case found:
+			ctx.Header(HeaderIdempotentReplayed, "true")
+			ctx.Data(r.Status, r.ContentType, r.Body)
+			ctx.Abort()
+			return
+		}
+
+		// response
+		// - the key is released when the handler fails or panics
+		rec := &recorder{ResponseWriter: ctx.Writer}
+		ctx.Writer = rec
+		completed := false
+		defer func() {
+			if !completed {
+				i.st.Release(key)
+			}
+		}()
+		ctx.Next()
+		if rec.Status() >= http.StatusInternalServerError {
+			return
+		}
+		i.st.Complete(key, IdempotencyRecord{
+			Fingerprint: fingerprint,
+			Status:      rec.Status(),
+			ContentType: rec.Header().Get("Content-Type"),
+			Body:        rec.body.Bytes(),
+		}, i.ttl, time.Now())
+		completed = true
+	}
+}
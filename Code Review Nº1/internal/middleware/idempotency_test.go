@@ -0,0 +1,211 @@
+package middleware_test
+
+import (
+	"Code_Review_N_1/internal/middleware"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// counter is a handler that counts the requests it serves.
+type counter struct {
+	n int
+}
+
+func (c *counter) Create() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		c.n++
+		ctx.JSON(http.StatusCreated, gin.H{"n": c.n})
+	}
+}
+
+func (c *counter) Other() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		c.n++
+		ctx.JSON(http.StatusCreated, gin.H{"n": c.n})
+	}
+}
+
+func TestIdempotency_Handler_Aliases(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ik := middleware.NewIdempotency(middleware.NewIdempotencyStoreMemory(), time.Hour, nil)
+	c := &counter{}
+	rt := gin.New()
+	for _, prefix := range []string{"/v1/things", "/things"} {
+		gr := rt.Group(prefix)
+		gr.POST("", ik.Handler(), c.Create())
+		gr.POST("/other", ik.Handler(), c.Other())
+	}
+
+	post := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{"a":1}`))
+		req.Header.Set(middleware.HeaderIdempotencyKey, "k1")
+		res := httptest.NewRecorder()
+		rt.ServeHTTP(res, req)
+		return res
+	}
+
+	first := post("/v1/things")
+	retry := post("/things")
+	if retry.Code != http.StatusCreated || retry.Header().Get(middleware.HeaderIdempotentReplayed) != "true" || retry.Body.String() != first.Body.String() {
+		t.Fatalf("retry through the alias = %d %q (replayed %q), want the first response %q replayed",
+			retry.Code, retry.Body, retry.Header().Get(middleware.HeaderIdempotentReplayed), first.Body)
+	}
+
+	other := post("/things/other")
+	if other.Header().Get(middleware.HeaderIdempotentReplayed) != "" || c.n != 2 {
+		t.Fatalf("same key on another route was replayed, served %d requests, want 2", c.n)
+	}
+}
+
+// flaky is a handler that fails with 500 the first fails requests it serves.
+type flaky struct {
+	n, fails int
+}
+
+func (f *flaky) Create() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		f.n++
+		if f.n <= f.fails {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+			return
+		}
+		ctx.JSON(http.StatusCreated, gin.H{"n": f.n})
+	}
+}
+
+func TestIdempotency_Handler(t *testing.T) {
+	type request struct {
+		body, key, apiKey string
+		// want are the status and the replayed header of the response
+		wantCode     int
+		wantReplayed bool
+	}
+	tests := []struct {
+		name     string
+		fails    int
+		requests []request
+		// served is the number of requests that reached the handler
+		served int
+	}{
+		{
+			name: "retry replays the stored response",
+			requests: []request{
+				{body: `{"a":1}`, key: "k", wantCode: http.StatusCreated},
+				{body: `{"a":1}`, key: "k", wantCode: http.StatusCreated, wantReplayed: true},
+			},
+			served: 1,
+		},
+		{
+			name: "key reused with a different body",
+			requests: []request{
+				{body: `{"a":1}`, key: "k", wantCode: http.StatusCreated},
+				{body: `{"a":2}`, key: "k", wantCode: http.StatusUnprocessableEntity},
+			},
+			served: 1,
+		},
+		{
+			name: "other key",
+			requests: []request{
+				{body: `{"a":1}`, key: "k", wantCode: http.StatusCreated},
+				{body: `{"a":1}`, key: "other", wantCode: http.StatusCreated},
+			},
+			served: 2,
+		},
+		{
+			name: "without key",
+			requests: []request{
+				{body: `{"a":1}`, wantCode: http.StatusCreated},
+				{body: `{"a":1}`, wantCode: http.StatusCreated},
+			},
+			served: 2,
+		},
+		{
+			name:  "key released after a server error",
+			fails: 1,
+			requests: []request{
+				{body: `{"a":1}`, key: "k", wantCode: http.StatusInternalServerError},
+				{body: `{"a":1}`, key: "k", wantCode: http.StatusCreated},
+				{body: `{"a":1}`, key: "k", wantCode: http.StatusCreated, wantReplayed: true},
+			},
+			served: 2,
+		},
+		{
+			name: "unknown api keys are scoped by ip",
+			requests: []request{
+				{body: `{"a":1}`, key: "k", apiKey: "invented", wantCode: http.StatusCreated},
+				{body: `{"a":1}`, key: "k", apiKey: "other", wantCode: http.StatusCreated, wantReplayed: true},
+			},
+			served: 1,
+		},
+		{
+			name: "known api keys are scoped by key",
+			requests: []request{
+				{body: `{"a":1}`, key: "k", apiKey: "k1", wantCode: http.StatusCreated},
+				{body: `{"a":1}`, key: "k", apiKey: "k2", wantCode: http.StatusCreated},
+				{body: `{"a":1}`, key: "k", wantCode: http.StatusCreated},
+			},
+			served: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			ik := middleware.NewIdempotency(middleware.NewIdempotencyStoreMemory(), time.Hour, []string{"k1", "k2"})
+			f := &flaky{fails: tt.fails}
+			rt := gin.New()
+			rt.POST("/things", ik.Handler(), f.Create())
+
+			var first string
+			for i, r := range tt.requests {
+				req := httptest.NewRequest(http.MethodPost, "/things", strings.NewReader(r.body))
+				req.RemoteAddr = "192.0.2.1:1234"
+				if r.key != "" {
+					req.Header.Set(middleware.HeaderIdempotencyKey, r.key)
+				}
+				if r.apiKey != "" {
+					req.Header.Set(middleware.HeaderAPIKey, r.apiKey)
+				}
+				res := httptest.NewRecorder()
+				rt.ServeHTTP(res, req)
+
+				replayed := res.Header().Get(middleware.HeaderIdempotentReplayed) == "true"
+				if res.Code != r.wantCode || replayed != r.wantReplayed {
+					t.Fatalf("request %d = %d (replayed %t), want %d (replayed %t)", i, res.Code, replayed, r.wantCode, r.wantReplayed)
+				}
+				if replayed && res.Body.String() != first {
+					t.Fatalf("request %d replayed %q, want %q", i, res.Body, first)
+				}
+				if res.Code == http.StatusCreated && !replayed {
+					first = res.Body.String()
+				}
+			}
+			if f.n != tt.served {
+				t.Fatalf("served %d requests, want %d", f.n, tt.served)
+			}
+		})
+	}
+}
+
+func TestIdempotencyStoreMemory_TTL(t *testing.T) {
+	st := middleware.NewIdempotencyStoreMemory()
+	now := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	ttl := time.Minute
+
+	if _, found := st.Reserve("k", "f", ttl, now); found {
+		t.Fatalf("first Reserve() found a record")
+	}
+	st.Complete("k", middleware.IdempotencyRecord{Fingerprint: "f", Status: http.StatusCreated}, ttl, now)
+
+	r, found := st.Reserve("k", "f", ttl, now.Add(ttl-time.Second))
+	if !found || !r.Done || r.Status != http.StatusCreated {
+		t.Fatalf("Reserve() before the ttl = %+v, %t, want the completed record", r, found)
+	}
+	if _, found := st.Reserve("k", "f", ttl, now.Add(ttl)); found {
+		t.Fatalf("Reserve() after the ttl found the expired record")
+	}
+}
@@ -0,0 +1,299 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	// ErrInvalidRateLimit is returned when a rate limit can not be parsed.
+	ErrInvalidRateLimit = errors.New("middleware: invalid rate limit")
+)
+
+const (
+	// HeaderAPIKey is the header that identifies a client by api key.
+	HeaderAPIKey = "X-API-Key"
+)
+
+// RateLimit is an struct that represents a token bucket: Requests tokens refilled every Per.
+type RateLimit struct {
+	// Requests is the capacity of the bucket.
+	Requests int
+	// Per is the time it takes to refill the whole bucket.
+	Per time.Duration
+}
+
+// rate returns the tokens refilled per second.
+func (l RateLimit) rate() float64 {
+	return float64(l.Requests) / l.Per.Seconds()
+}
+
+// ParseRateLimit parses a rate limit in the form "<requests>/<duration>" (e.g. "10/1m").
+func ParseRateLimit(s string) (l RateLimit, err error) {
+	requests, per, ok := strings.Cut(strings.TrimSpace(s), "/")
+	if !ok {
+		err = fmt.Errorf("%w: %q", ErrInvalidRateLimit, s)
+		return
+	}
+	l.Requests, err = strconv.Atoi(requests)
+	if err != nil || l.Requests <= 0 {
+		err = fmt.Errorf("%w: %q", ErrInvalidRateLimit, s)
+		return
+	}
+	l.Per, err = time.ParseDuration(per)
+	if err != nil || l.Per <= 0 {
+		err = fmt.Errorf("%w: %q", ErrInvalidRateLimit, s)
+		return
+	}
+	return
+}
+
+// ParseRateLimits parses per route rate limits in the form "METHOD /path=<requests>/<duration>,..."
+// - paths use gin syntax (e.g. "PUT /vehicles/:id/update_speed=5/1s")
+func ParseRateLimits(s string) (l map[string]RateLimit, err error) {
+	l = make(map[string]RateLimit)
+	for _, entry := range strings.Split(s, ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		route, limit, ok := strings.Cut(entry, "=")
+		if !ok {
+			err = fmt.Errorf("%w: %q", ErrInvalidRateLimit, entry)
+			return
+		}
+		l[strings.Join(strings.Fields(route), " ")], err = ParseRateLimit(limit)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// RateLimitResult is an struct that represents the outcome of taking a token from a bucket.
+type RateLimitResult struct {
+	// Allowed is true when a token was taken.
+	Allowed bool
+	// Remaining is the number of whole tokens left in the bucket.
+	Remaining int
+	// Reset is the time until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is the time until a token is available, when not allowed.
+	RetryAfter time.Duration
+}
+
+// RateLimitStore is the interface that wraps the basic methods for a store of token buckets.
+type RateLimitStore interface {
+	// Take takes a token from the bucket of key.
+	Take(key string, limit RateLimit, now time.Time) RateLimitResult
+}
+
+// NewRateLimitStoreMemory returns a new instance of an in-memory token bucket store.
+// - buckets not used for idle are evicted
+// - at most maxBuckets are kept, the least recently used one is evicted to make room for a new one
+func NewRateLimitStoreMemory(idle time.Duration, maxBuckets int) *RateLimitStoreMemory {
+	return &RateLimitStoreMemory{
+		buckets:    make(map[string]*bucket),
+		idle:       idle,
+		maxBuckets: maxBuckets,
+	}
+}
+
+// bucket is an struct that represents the state of a token bucket.
+type bucket struct {
+	// tokens is the number of tokens at last.
+	tokens float64
+	// last is the last time the bucket was used.
+	last time.Time
+}
+
+// RateLimitStoreMemory is an struct that represents an in-memory store of token buckets.
+type RateLimitStoreMemory struct {
+	// mu guards buckets and lastSweep.
+	mu sync.Mutex
+	// buckets are the token buckets by key.
+	buckets map[string]*bucket
+	// idle is the time after which an unused bucket is evicted.
+	idle time.Duration
+	// maxBuckets is the maximum number of buckets kept.
+	maxBuckets int
+	// lastSweep is the last time idle buckets were evicted.
+	lastSweep time.Time
+}
+
+// Take takes a token from the bucket of key.
+func (s *RateLimitStoreMemory) Take(key string, limit RateLimit, now time.Time) (r RateLimitResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// evict idle buckets
+	if now.Sub(s.lastSweep) >= s.idle {
+		s.evict(now)
+	}
+
+	// refill the bucket
+	capacity := float64(limit.Requests)
+	b, ok := s.buckets[key]
+	if !ok {
+		if len(s.buckets) >= s.maxBuckets {
+			s.evict(now)
+		}
+		if len(s.buckets) >= s.maxBuckets {
+			s.evictOldest()
+		}
+		b = &bucket{tokens: capacity, last: now}
+		s.buckets[key] = b
+	}
+	b.tokens = math.Min(capacity, b.tokens+now.Sub(b.last).Seconds()*limit.rate())
+	b.last = now
+
+	// take a token
+	if b.tokens >= 1 {
+		b.tokens--
+		r.Allowed = true
+	} else {
+		r.RetryAfter = seconds((1 - b.tokens) / limit.rate())
+	}
+	r.Remaining = int(b.tokens)
+	r.Reset = seconds((capacity - b.tokens) / limit.rate())
+	return
+}
+
+// evict removes the buckets not used for idle.
+func (s *RateLimitStoreMemory) evict(now time.Time) {
+	for key, b := range s.buckets {
+		if now.Sub(b.last) >= s.idle {
+			delete(s.buckets, key)
+		}
+	}
+	s.lastSweep = now
+}
+
+// evictOldest removes the least recently used bucket.
+func (s *RateLimitStoreMemory) evictOldest() {
+	var oldestKey string
+	var oldest time.Time
+	for key, b := range s.buckets {
+		if oldestKey == "" || b.last.Before(oldest) {
+			oldestKey, oldest = key, b.last
+		}
+	}
+	delete(s.buckets, oldestKey)
+}
+
+// seconds converts a number of seconds to a duration.
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// ConfigRateLimiter is an struct that contains the configuration of a rate limiter.
+type ConfigRateLimiter struct {
+	// Default is the limit of the routes without a specific limit.
+	Default RateLimit
+	// Routes are the limits by route ("METHOD /gin/path"), shared by the aliases of the route.
+	Routes map[string]RateLimit
+	// APIKeys are the keys that identify a client, any other key is ignored.
+	APIKeys []string
+}
+
+// NewRateLimiter returns a new instance of a rate limiter.
+func NewRateLimiter(cfg ConfigRateLimiter, st RateLimitStore) *RateLimiter {
+	apiKeys := make(map[string]struct{}, len(cfg.APIKeys))
+	for _, key := range cfg.APIKeys {
+		apiKeys[key] = struct{}{}
+	}
+	return &RateLimiter{
+		dflt:     cfg.Default,
+		routes:   cfg.Routes,
+		handlers: make(map[string]RateLimit),
+		apiKeys:  apiKeys,
+		st:       st,
+	}
+}
+
+// RateLimiter is an struct that limits the requests of every client to every route.
+// - clients are identified by api key, or by ip when the key is missing or unknown
+// - the ip is the one of the connection unless the router trusts the proxy that forwarded it
+// - the buckets are kept by handler, so the aliases of a route share them
+type RateLimiter struct {
+	// dflt is the limit of the routes without a specific limit.
+	dflt RateLimit
+	// routes are the limits by route.
+	routes map[string]RateLimit
+	// handlers are the limits of the routes by "METHOD handler name", set by Bind.
+	handlers map[string]RateLimit
+	// apiKeys are the known api keys.
+	apiKeys map[string]struct{}
+	// st is the store of token buckets.
+	st RateLimitStore
+}
+
+// Bind resolves the limits by route to the handlers of the routes of a router.
+// - it must be called once every route is registered, before serving
+// - the aliases of a route cannot have different limits
+func (r *RateLimiter) Bind(routes gin.RoutesInfo) error {
+	handlers := make(map[string]string, len(routes))
+	for _, ri := range routes {
+		handlers[ri.Method+" "+ri.Path] = ri.Method + " " + ri.Handler
+	}
+	for route, limit := range r.routes {
+		handler, ok := handlers[route]
+		if !ok {
+			return fmt.Errorf("middleware: rate limit of unknown route %q", route)
+		}
+		if l, ok := r.handlers[handler]; ok && l != limit {
+			return fmt.Errorf("middleware: different rate limits of the aliases of route %q", route)
+		}
+		r.handlers[handler] = limit
+	}
+	return nil
+}
+
+// Handler returns the middleware that rejects the requests over the limit with 429.
+func (r *RateLimiter) Handler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// request
+		route := ctx.Request.Method + " " + ctx.HandlerName()
+		limit, ok := r.handlers[route]
+		if !ok {
+			limit = r.dflt
+		}
+		client := clientID(ctx, r.apiKeys)
+
+		// process
+		res := r.st.Take(client+" "+route, limit, time.Now())
+
+		// response
+		ctx.Header("RateLimit-Limit", strconv.Itoa(limit.Requests))
+		ctx.Header("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		ctx.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+		if !res.Allowed {
+			ctx.Header("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter)))
+			ctx.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "too many requests"})
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// clientID returns the identity of the client of a request: its api key when known, its ip otherwise.
+func clientID(ctx *gin.Context, apiKeys map[string]struct{}) string {
+	if key := ctx.GetHeader(HeaderAPIKey); key != "" {
+		if _, ok := apiKeys[key]; ok {
+			return "key:" + key
+		}
+	}
+	return "ip:" + ctx.ClientIP()
+}
+
+// ceilSeconds rounds a duration up to whole seconds.
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,170 @@
+package middleware_test
+
+import (
+	"Code_Review_N_1/internal/middleware"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newRateLimitedRouter(t *testing.T, apiKeys []string) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	rl := middleware.NewRateLimiter(middleware.ConfigRateLimiter{
+		Default: middleware.RateLimit{Requests: 1, Per: time.Hour},
+		APIKeys: apiKeys,
+	}, middleware.NewRateLimitStoreMemory(time.Hour, 100))
+	rt := gin.New()
+	if err := rt.SetTrustedProxies(nil); err != nil {
+		t.Fatalf("SetTrustedProxies() error = %v", err)
+	}
+	rt.Use(rl.Handler())
+	rt.GET("/ping", func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+	return rt
+}
+
+func TestRateLimiter_Handler_Client(t *testing.T) {
+	tests := []struct {
+		name string
+		// header is set on the second request, from the same connection as the first one
+		header, value string
+		want          int
+	}{
+		{name: "spoofed forwarded ip", header: "X-Forwarded-For", value: "203.0.113.7", want: http.StatusTooManyRequests},
+		{name: "spoofed real ip", header: "X-Real-IP", value: "203.0.113.7", want: http.StatusTooManyRequests},
+		{name: "unknown api key", header: middleware.HeaderAPIKey, value: "invented", want: http.StatusTooManyRequests},
+		{name: "known api key", header: middleware.HeaderAPIKey, value: "k1", want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt := newRateLimitedRouter(t, []string{"k1"})
+
+			first := httptest.NewRequest(http.MethodGet, "/ping", nil)
+			first.RemoteAddr = "192.0.2.1:1234"
+			res := httptest.NewRecorder()
+			rt.ServeHTTP(res, first)
+			if res.Code != http.StatusOK {
+				t.Fatalf("first request status = %d, want %d", res.Code, http.StatusOK)
+			}
+
+			second := httptest.NewRequest(http.MethodGet, "/ping", nil)
+			second.RemoteAddr = "192.0.2.1:1235"
+			second.Header.Set(tt.header, tt.value)
+			res = httptest.NewRecorder()
+			rt.ServeHTTP(res, second)
+			if res.Code != tt.want {
+				t.Fatalf("second request status = %d, want %d", res.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimitStoreMemory_Take_Eviction(t *testing.T) {
+	limit := middleware.RateLimit{Requests: 1, Per: time.Hour}
+	now := time.Now()
+
+	t.Run("idle buckets", func(t *testing.T) {
+		st := middleware.NewRateLimitStoreMemory(time.Minute, 100)
+		st.Take("a", limit, now)
+
+		// a full bucket again once forgotten, refilling would take an hour
+		if r := st.Take("a", limit, now.Add(2*time.Minute)); !r.Allowed {
+			t.Fatalf("Take() of an idle bucket = %+v, want allowed", r)
+		}
+	})
+
+	t.Run("least recently used bucket over the maximum", func(t *testing.T) {
+		st := middleware.NewRateLimitStoreMemory(time.Hour, 2)
+		st.Take("a", limit, now)
+		st.Take("b", limit, now.Add(time.Second))
+		st.Take("c", limit, now.Add(2*time.Second))
+
+		if r := st.Take("b", limit, now.Add(3*time.Second)); r.Allowed {
+			t.Fatalf("Take() of a kept bucket = %+v, want not allowed", r)
+		}
+		if r := st.Take("a", limit, now.Add(4*time.Second)); !r.Allowed {
+			t.Fatalf("Take() of an evicted bucket = %+v, want allowed", r)
+		}
+	})
+}
+
+func TestRateLimiter_Handler_Aliases(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	rl := middleware.NewRateLimiter(middleware.ConfigRateLimiter{
+		Default: middleware.RateLimit{Requests: 100, Per: time.Hour},
+		Routes:  map[string]middleware.RateLimit{"POST /v1/things": {Requests: 2, Per: time.Hour}},
+	}, middleware.NewRateLimitStoreMemory(time.Hour, 100))
+	c := &counter{}
+	rt := gin.New()
+	rt.Use(rl.Handler())
+	for _, prefix := range []string{"/v1/things", "/things"} {
+		gr := rt.Group(prefix)
+		gr.POST("", c.Create())
+		gr.POST("/other", c.Other())
+	}
+	if err := rl.Bind(rt.Routes()); err != nil {
+		t.Fatalf("Bind() error = %v", err)
+	}
+
+	post := func(path string) int {
+		res := httptest.NewRecorder()
+		rt.ServeHTTP(res, httptest.NewRequest(http.MethodPost, path, nil))
+		return res.Code
+	}
+
+	if code := post("/v1/things"); code != http.StatusCreated {
+		t.Fatalf("first request status = %d, want %d", code, http.StatusCreated)
+	}
+	if code := post("/things"); code != http.StatusCreated {
+		t.Fatalf("second request, through the alias, status = %d, want %d", code, http.StatusCreated)
+	}
+	if code := post("/v1/things"); code != http.StatusTooManyRequests {
+		t.Fatalf("third request status = %d, want %d", code, http.StatusTooManyRequests)
+	}
+	if code := post("/things/other"); code != http.StatusCreated {
+		t.Fatalf("request to another route status = %d, want %d", code, http.StatusCreated)
+	}
+}
+
+func TestRateLimiter_Bind_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		routes map[string]middleware.RateLimit
+		want   string
+	}{
+		{
+			name:   "unknown route",
+			routes: map[string]middleware.RateLimit{"POST /missing": {Requests: 1, Per: time.Hour}},
+			want:   "unknown route",
+		},
+		{
+			name: "aliases with different limits",
+			routes: map[string]middleware.RateLimit{
+				"POST /v1/things": {Requests: 1, Per: time.Hour},
+				"POST /things":    {Requests: 2, Per: time.Hour},
+			},
+			want: "different rate limits",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			rl := middleware.NewRateLimiter(middleware.ConfigRateLimiter{Routes: tt.routes}, middleware.NewRateLimitStoreMemory(time.Hour, 100))
+			c := &counter{}
+			rt := gin.New()
+			rt.POST("/v1/things", c.Create())
+			rt.POST("/things", c.Create())
+
+			err := rl.Bind(rt.Routes())
+
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Bind() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
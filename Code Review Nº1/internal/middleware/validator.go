@@ -0,0 +1,467 @@
+package middleware
+
+import (
+	"Code_Review_N_1/docs"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// paramsKey is the key of the gin context where the validated parameters are stored.
+	paramsKey = "middleware.validator.params"
+)
+
+// Violation is an struct that represents a rule of the api schema broken by a request.
+type Violation struct {
+	// In is the location of the value: path, query or body.
+	In string `json:"in"`
+	// Name is the name of the parameter or the path of the body field.
+	Name string `json:"name"`
+	// Message describes the violation.
+	Message string `json:"message"`
+}
+
+// schema is an struct that represents the subset of JSON Schema used by the api document.
+type schema struct {
+	Ref        string             `json:"$ref"`
+	Type       string             `json:"type"`
+	Format     string             `json:"format"`
+	Enum       []any              `json:"enum"`
+	Minimum    *float64           `json:"minimum"`
+	Required   []string           `json:"required"`
+	Properties map[string]*schema `json:"properties"`
+	Items      *schema            `json:"items"`
+	// AdditionalProperties is either a boolean or the schema of the properties not listed in Properties.
+	AdditionalProperties json.RawMessage `json:"additionalProperties"`
+}
+
+// additional returns the schema of the properties of an object not listed in its properties.
+// - allowed is false when the schema forbids them, sc is nil when any value is allowed
+func (s *schema) additional() (sc *schema, allowed bool) {
+	switch raw := bytes.TrimSpace(s.AdditionalProperties); {
+	case len(raw) == 0, bytes.Equal(raw, []byte("true")):
+		return nil, true
+	case bytes.Equal(raw, []byte("false")):
+		return nil, false
+	}
+	sc = &schema{}
+	if err := json.Unmarshal(s.AdditionalProperties, sc); err != nil {
+		return nil, true
+	}
+	return sc, true
+}
+
+// parameter is an struct that represents an operation parameter of the api document.
+type parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *schema `json:"schema"`
+}
+
+// operation is an struct that represents an operation of the api document.
+type operation struct {
+	Parameters  []parameter `json:"parameters"`
+	RequestBody *struct {
+		Required bool `json:"required"`
+		Content  map[string]struct {
+			Schema *schema `json:"schema"`
+		} `json:"content"`
+	} `json:"requestBody"`
+}
+
+// NewValidator returns a new instance of a request validator built from an OpenAPI document.
+func NewValidator(spec []byte) (v *Validator, err error) {
+	// decode the document
+	var doc struct {
+		Paths      map[string]map[string]json.RawMessage `json:"paths"`
+		Components struct {
+			Schemas map[string]*schema `json:"schemas"`
+		} `json:"components"`
+	}
+	err = json.Unmarshal(spec, &doc)
+	if err != nil {
+		return
+	}
+
+	// index the operations by method and gin path
+	v = &Validator{
+		operations: make(map[string]operation),
+		schemas:    doc.Components.Schemas,
+	}
+	for path, item := range doc.Paths {
+		for method, raw := range item {
+			var op operation
+			if err = json.Unmarshal(raw, &op); err != nil {
+				err = fmt.Errorf("middleware: invalid operation %s %s: %w", method, path, err)
+				return
+			}
+			v.operations[strings.ToUpper(method)+" "+docs.GinPath(path)] = op
+		}
+	}
+	return
+}
+
+// Validator is an struct that validates requests against the operations of an OpenAPI document.
+type Validator struct {
+	// operations are the documented operations by "METHOD /gin/path".
+	operations map[string]operation
+	// schemas are the reusable schemas of the document.
+	schemas map[string]*schema
+}
+
+// RenderViolations writes the response of a request rejected by the validator.
+type RenderViolations func(ctx *gin.Context, violations []Violation)
+
+// Handler returns the middleware that rejects requests that do not match the api document.
+// - the coerced path, query and form parameters are available to the handlers through ParamInt and ParamString
+func (v *Validator) Handler() gin.HandlerFunc {
+	return v.HandlerWith(renderViolations)
+}
+
+// HandlerWith returns the validator middleware rendering the rejected requests with render.
+func (v *Validator) HandlerWith(render RenderViolations) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// request
+		op, ok := v.operations[ctx.Request.Method+" "+ctx.FullPath()]
+		if !ok {
+			// undocumented route (e.g. not found), nothing to validate
+			ctx.Next()
+			return
+		}
+
+		// process
+		params := make(map[string]any)
+		var violations []Violation
+		// - parameters
+		for _, p := range op.Parameters {
+			var raw string
+			var found bool
+			switch p.In {
+			case "path":
+				raw = ctx.Param(p.Name)
+				found = raw != ""
+			case "query":
+				raw, found = ctx.GetQuery(p.Name)
+			case "header":
+				raw = ctx.GetHeader(p.Name)
+				found = raw != ""
+			default:
+				continue
+			}
+			if !found {
+				if p.Required {
+					violations = append(violations, Violation{In: p.In, Name: p.Name, Message: "is required"})
+				}
+				continue
+			}
+			value, msg := v.coerce(p.Schema, raw)
+			if msg != "" {
+				violations = append(violations, Violation{In: p.In, Name: p.Name, Message: msg})
+				continue
+			}
+			params[p.Name] = value
+		}
+		// - body
+		if op.RequestBody != nil {
+			violations = append(violations, v.validateBody(ctx, op, params)...)
+		}
+
+		// response
+		if len(violations) > 0 {
+			render(ctx, violations)
+			ctx.Abort()
+			return
+		}
+		ctx.Set(paramsKey, params)
+		ctx.Next()
+	}
+}
+
+// renderViolations is the default response of a rejected request.
+func renderViolations(ctx *gin.Context, violations []Violation) {
+	ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request", "violations": violations})
+}
+
+// validateBody validates the request body against the schema of its content type.
+func (v *Validator) validateBody(ctx *gin.Context, op operation, params map[string]any) (violations []Violation) {
+	// form bodies are validated field by field like parameters
+	if content, ok := op.RequestBody.Content[gin.MIMEPOSTForm]; ok && ctx.ContentType() != gin.MIMEJSON {
+		sc := v.resolve(content.Schema)
+		if sc == nil {
+			return
+		}
+		required := make(map[string]bool, len(sc.Required))
+		for _, name := range sc.Required {
+			required[name] = true
+		}
+		names := make([]string, 0, len(sc.Properties))
+		for name := range sc.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			raw, found := ctx.GetPostForm(name)
+			if !found {
+				if required[name] {
+					violations = append(violations, Violation{In: "body", Name: name, Message: "is required"})
+				}
+				continue
+			}
+			value, msg := v.coerce(sc.Properties[name], raw)
+			if msg != "" {
+				violations = append(violations, Violation{In: "body", Name: name, Message: msg})
+				continue
+			}
+			params[name] = value
+		}
+		return
+	}
+
+	content, ok := op.RequestBody.Content[gin.MIMEJSON]
+	if !ok {
+		return
+	}
+	// read the body and put it back for the handler
+	b, err := io.ReadAll(ctx.Request.Body)
+	if err != nil {
+		violations = append(violations, Violation{In: "body", Message: "could not be read"})
+		return
+	}
+	ctx.Request.Body = io.NopCloser(bytes.NewReader(b))
+	if len(bytes.TrimSpace(b)) == 0 {
+		if op.RequestBody.Required {
+			violations = append(violations, Violation{In: "body", Message: "is required"})
+		}
+		return
+	}
+
+	var body any
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err = dec.Decode(&body); err != nil {
+		violations = append(violations, Violation{In: "body", Message: "invalid json"})
+		return
+	}
+	v.validate(content.Schema, body, "", &violations)
+	return
+}
+
+// validate checks a decoded json value against a schema, collecting the violations.
+func (v *Validator) validate(sc *schema, value any, name string, violations *[]Violation) {
+	sc = v.resolve(sc)
+	if sc == nil {
+		return
+	}
+
+	switch sc.Type {
+	case "object":
+		obj, ok := value.(map[string]any)
+		if !ok {
+			*violations = append(*violations, Violation{In: "body", Name: name, Message: "must be an object"})
+			return
+		}
+		for _, field := range sc.Required {
+			if _, ok := obj[field]; !ok {
+				*violations = append(*violations, Violation{In: "body", Name: join(name, field), Message: "is required"})
+			}
+		}
+		fields := make([]string, 0, len(obj))
+		for field := range obj {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		extra, allowed := sc.additional()
+		for _, field := range fields {
+			fs, ok := sc.Properties[field]
+			switch {
+			case ok:
+				v.validate(fs, obj[field], join(name, field), violations)
+			case !allowed:
+				*violations = append(*violations, Violation{In: "body", Name: join(name, field), Message: "is not allowed"})
+			case extra != nil:
+				v.validate(extra, obj[field], join(name, field), violations)
+			}
+		}
+	case "array":
+		arr, ok := value.([]any)
+		if !ok {
+			*violations = append(*violations, Violation{In: "body", Name: name, Message: "must be an array"})
+			return
+		}
+		for i, item := range arr {
+			v.validate(sc.Items, item, fmt.Sprintf("%s[%d]", name, i), violations)
+		}
+	case "integer":
+		n, ok := value.(json.Number)
+		i, err := n.Int64()
+		if !ok || err != nil {
+			*violations = append(*violations, Violation{In: "body", Name: name, Message: "must be an integer"})
+			return
+		}
+		if msg := checkRules(sc, i); msg != "" {
+			*violations = append(*violations, Violation{In: "body", Name: name, Message: msg})
+		}
+	case "number":
+		n, ok := value.(json.Number)
+		f, err := n.Float64()
+		if !ok || err != nil {
+			*violations = append(*violations, Violation{In: "body", Name: name, Message: "must be a number"})
+			return
+		}
+		if msg := checkRules(sc, f); msg != "" {
+			*violations = append(*violations, Violation{In: "body", Name: name, Message: msg})
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			*violations = append(*violations, Violation{In: "body", Name: name, Message: "must be a boolean"})
+		}
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			*violations = append(*violations, Violation{In: "body", Name: name, Message: "must be a string"})
+			return
+		}
+		if msg := checkFormat(sc.Format, s); msg != "" {
+			*violations = append(*violations, Violation{In: "body", Name: name, Message: msg})
+			return
+		}
+		if msg := checkRules(sc, s); msg != "" {
+			*violations = append(*violations, Violation{In: "body", Name: name, Message: msg})
+		}
+	}
+}
+
+// coerce converts a raw parameter value to the type of its schema.
+// - msg is empty when the value is valid
+func (v *Validator) coerce(sc *schema, raw string) (value any, msg string) {
+	sc = v.resolve(sc)
+	if sc == nil {
+		return raw, ""
+	}
+
+	switch sc.Type {
+	case "integer":
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, "must be an integer"
+		}
+		return n, checkRules(sc, n)
+	case "number":
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, "must be a number"
+		}
+		return f, checkRules(sc, f)
+	case "boolean":
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, "must be a boolean"
+		}
+		return b, ""
+	default:
+		if msg = checkFormat(sc.Format, raw); msg != "" {
+			return raw, msg
+		}
+		return raw, checkRules(sc, raw)
+	}
+}
+
+// resolve follows the reference of a schema to the components of the document.
+func (v *Validator) resolve(sc *schema) *schema {
+	for sc != nil && sc.Ref != "" {
+		sc = v.schemas[strings.TrimPrefix(sc.Ref, "#/components/schemas/")]
+	}
+	return sc
+}
+
+// checkFormat validates the string formats used by the api document.
+func checkFormat(format, s string) (msg string) {
+	switch format {
+	case "date":
+		if _, err := time.Parse(time.DateOnly, s); err != nil {
+			return "must be a date (YYYY-MM-DD)"
+		}
+	}
+	return
+}
+
+// checkRules validates a value, already of the type of its schema, against the enum and minimum of the schema.
+func checkRules(sc *schema, value any) (msg string) {
+	if len(sc.Enum) > 0 {
+		allowed := make([]string, len(sc.Enum))
+		found := false
+		for i, e := range sc.Enum {
+			allowed[i] = fmt.Sprint(e)
+			found = found || allowed[i] == fmt.Sprint(value)
+		}
+		if !found {
+			return "must be one of: " + strings.Join(allowed, ", ")
+		}
+	}
+	if sc.Minimum != nil {
+		var n float64
+		switch value := value.(type) {
+		case int:
+			n = float64(value)
+		case int64:
+			n = float64(value)
+		case float64:
+			n = value
+		default:
+			return
+		}
+		if n < *sc.Minimum {
+			return fmt.Sprintf("must be at least %v", *sc.Minimum)
+		}
+	}
+	return
+}
+
+// join returns the dotted path of a body field.
+func join(parent, field string) string {
+	if parent == "" {
+		return field
+	}
+	return parent + "." + field
+}
+
+// ParamInt returns an integer parameter validated by the validator middleware.
+func ParamInt(ctx *gin.Context, name string) int {
+	n, _ := params(ctx)[name].(int)
+	return n
+}
+
+// ParamFloat returns a number parameter validated by the validator middleware.
+func ParamFloat(ctx *gin.Context, name string) float64 {
+	f, _ := params(ctx)[name].(float64)
+	return f
+}
+
+// ParamString returns a string parameter validated by the validator middleware.
+func ParamString(ctx *gin.Context, name string) string {
+	s, _ := params(ctx)[name].(string)
+	return s
+}
+
+// HasParam reports whether a parameter was present in the request validated by the validator middleware.
+func HasParam(ctx *gin.Context, name string) bool {
+	_, ok := params(ctx)[name]
+	return ok
+}
+
+// params returns the parameters stored by the validator middleware.
+func params(ctx *gin.Context) map[string]any {
+	p, _ := ctx.Get(paramsKey)
+	m, _ := p.(map[string]any)
+	return m
+}
@@ -0,0 +1,100 @@
+package middleware_test
+
+import (
+	"Code_Review_N_1/internal/middleware"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// validatorSpec is an api document with an operation of every kind of parameter and a json body.
+const validatorSpec = `{
+  "paths": {
+    "/things": {
+      "get": {
+        "parameters": [
+          {"name": "limit", "in": "query", "schema": {"type": "integer", "minimum": 1}},
+          {"name": "sort", "in": "query", "schema": {"type": "string", "enum": ["name", "-name"]}}
+        ]
+      },
+      "post": {
+        "requestBody": {
+          "required": true,
+          "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Thing"}}}
+        }
+      }
+    }
+  },
+  "components": {
+    "schemas": {
+      "Thing": {
+        "type": "object",
+        "required": ["name", "size"],
+        "additionalProperties": false,
+        "properties": {
+          "name": {"type": "string"},
+          "size": {"type": "integer", "minimum": 0},
+          "kind": {"type": "string", "enum": ["a", "b"]},
+          "tags": {"type": "array", "items": {"type": "string"}}
+        }
+      }
+    }
+  }
+}`
+
+func TestValidator_Handler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	vd, err := middleware.NewValidator([]byte(validatorSpec))
+	if err != nil {
+		t.Fatalf("NewValidator() error = %v", err)
+	}
+	rt := gin.New()
+	rt.Use(vd.Handler())
+	rt.GET("/things", func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, gin.H{"limit": middleware.ParamInt(ctx, "limit"), "sort": middleware.ParamString(ctx, "sort")})
+	})
+	rt.POST("/things", func(ctx *gin.Context) {
+		ctx.Status(http.StatusCreated)
+	})
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		code   int
+		want   string
+	}{
+		{name: "coerced query params", method: http.MethodGet, target: "/things?limit=5&sort=-name", code: http.StatusOK, want: `{"limit":5,"sort":"-name"}`},
+		{name: "query param of wrong type", method: http.MethodGet, target: "/things?limit=five", code: http.StatusBadRequest, want: `"name":"limit","message":"must be an integer"`},
+		{name: "query param below minimum", method: http.MethodGet, target: "/things?limit=0", code: http.StatusBadRequest, want: `"name":"limit","message":"must be at least 1"`},
+		{name: "query param out of enum", method: http.MethodGet, target: "/things?sort=bogus", code: http.StatusBadRequest, want: `"name":"sort","message":"must be one of: name, -name"`},
+		{name: "valid body", method: http.MethodPost, target: "/things", body: `{"name":"x","size":0,"kind":"a","tags":["t"]}`, code: http.StatusCreated},
+		{name: "missing body", method: http.MethodPost, target: "/things", code: http.StatusBadRequest, want: `"in":"body","name":"","message":"is required"`},
+		{name: "missing required body field", method: http.MethodPost, target: "/things", body: `{"name":"x"}`, code: http.StatusBadRequest, want: `"name":"size","message":"is required"`},
+		{name: "body field of wrong type", method: http.MethodPost, target: "/things", body: `{"name":"x","size":"big"}`, code: http.StatusBadRequest, want: `"name":"size","message":"must be an integer"`},
+		{name: "body field below minimum", method: http.MethodPost, target: "/things", body: `{"name":"x","size":-1}`, code: http.StatusBadRequest, want: `"name":"size","message":"must be at least 0"`},
+		{name: "body field out of enum", method: http.MethodPost, target: "/things", body: `{"name":"x","size":1,"kind":"c"}`, code: http.StatusBadRequest, want: `"name":"kind","message":"must be one of: a, b"`},
+		{name: "body array item of wrong type", method: http.MethodPost, target: "/things", body: `{"name":"x","size":1,"tags":[1]}`, code: http.StatusBadRequest, want: `"name":"tags[0]","message":"must be a string"`},
+		{name: "body field not allowed", method: http.MethodPost, target: "/things", body: `{"name":"x","size":1,"color":"red"}`, code: http.StatusBadRequest, want: `"name":"color","message":"is not allowed"`},
+		{name: "invalid json", method: http.MethodPost, target: "/things", body: `{"name":`, code: http.StatusBadRequest, want: `"message":"invalid json"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			res := httptest.NewRecorder()
+			rt.ServeHTTP(res, req)
+
+			if res.Code != tt.code {
+				t.Fatalf("status = %d, want %d: %s", res.Code, tt.code, res.Body)
+			}
+			if !strings.Contains(res.Body.String(), tt.want) {
+				t.Fatalf("body = %s, want %s", res.Body, tt.want)
+			}
+		})
+	}
+}
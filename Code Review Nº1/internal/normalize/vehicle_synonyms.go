@@ -0,0 +1,158 @@
+package normalize
+
+import (
+	"Code_Review_N_1/internal"
+	"encoding/json"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// Synonyms is an struct that contains the synonyms of every categorical attribute.
+// - keys are matched case-insensitively, values are the canonical forms
+type Synonyms struct {
+	Brand        map[string]string `json:"brand"`
+	Color        map[string]string `json:"color"`
+	FuelType     map[string]string `json:"fuel_type"`
+	Transmission map[string]string `json:"transmission"`
+}
+
+// DefaultSynonyms returns the synonyms known for the vehicles dataset.
+func DefaultSynonyms() Synonyms {
+	return Synonyms{
+		Brand: map[string]string{
+			"bmw":      "BMW",
+			"gmc":      "GMC",
+			"chevy":    "Chevrolet",
+			"vw":       "Volkswagen",
+			"mercedes": "Mercedes-Benz",
+			"benz":     "Mercedes-Benz",
+		},
+		Color: map[string]string{
+			"mauv":   "Mauve",
+			"fuscia": "Fuchsia",
+			"grey":   "Gray",
+		},
+		FuelType: map[string]string{
+			"gas":    "gasoline",
+			"petrol": "gasoline",
+		},
+		Transmission: map[string]string{
+			"auto":           "automatic",
+			"semi automatic": "semi-automatic",
+			"semiautomatic":  "semi-automatic",
+			"semi-auto":      "semi-automatic",
+		},
+	}
+}
+
+// LoadSynonyms returns the default synonyms extended with the ones of a json file.
+func LoadSynonyms(path string) (s Synonyms, err error) {
+	s = DefaultSynonyms()
+
+	// read file
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	var file Synonyms
+	err = json.NewDecoder(f).Decode(&file)
+	if err != nil {
+		return
+	}
+
+	// merge
+	merge := func(dst, src map[string]string) {
+		for k, v := range src {
+			dst[k] = v
+		}
+	}
+	merge(s.Brand, file.Brand)
+	merge(s.Color, file.Color)
+	merge(s.FuelType, file.FuelType)
+	merge(s.Transmission, file.Transmission)
+	return
+}
+
+// NewVehicleSynonyms returns a new instance of a vehicle normalizer.
+func NewVehicleSynonyms(s Synonyms) *VehicleSynonyms {
+	fold := func(m map[string]string) map[string]string {
+		folded := make(map[string]string, len(m))
+		for k, v := range m {
+			folded[key(k)] = v
+		}
+		return folded
+	}
+	return &VehicleSynonyms{
+		brand:        fold(s.Brand),
+		color:        fold(s.Color),
+		fuelType:     fold(s.FuelType),
+		transmission: fold(s.Transmission),
+	}
+}
+
+// VehicleSynonyms is an struct that normalizes the categorical attributes of a vehicle.
+// - values are trimmed, their spaces collapsed and their case folded, then replaced by their synonym
+// - without synonym, brands and colors are title cased, fuel types and transmissions lower cased
+type VehicleSynonyms struct {
+	brand        map[string]string
+	color        map[string]string
+	fuelType     map[string]string
+	transmission map[string]string
+}
+
+// Normalize returns the canonical categorical attributes of a vehicle.
+func (n *VehicleSynonyms) Normalize(a internal.VehicleAttributes) internal.VehicleCategories {
+	return internal.VehicleCategories{
+		Brand:        n.Brand(a.Brand),
+		Color:        n.Color(a.Color),
+		FuelType:     n.FuelType(a.FuelType),
+		Transmission: n.Transmission(a.Transmission),
+	}
+}
+
+// Brand returns the canonical form of a brand.
+func (n *VehicleSynonyms) Brand(s string) string {
+	return canonical(n.brand, s, title)
+}
+
+// Color returns the canonical form of a color.
+func (n *VehicleSynonyms) Color(s string) string {
+	return canonical(n.color, s, title)
+}
+
+// FuelType returns the canonical form of a fuel type.
+func (n *VehicleSynonyms) FuelType(s string) string {
+	return canonical(n.fuelType, s, strings.ToLower)
+}
+
+// Transmission returns the canonical form of a transmission.
+func (n *VehicleSynonyms) Transmission(s string) string {
+	return canonical(n.transmission, s, strings.ToLower)
+}
+
+// canonical returns the synonym of s, or s in its canonical casing.
+func canonical(synonyms map[string]string, s string, casing func(string) string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if v, ok := synonyms[key(s)]; ok {
+		return v
+	}
+	return casing(s)
+}
+
+// key returns the case folded form of s used to look up the synonyms.
+func key(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
+
+// title upper cases the first letter of every word and lower cases the rest (aston martin -> Aston Martin).
+func title(s string) string {
+	r := []rune(strings.ToLower(s))
+	for i := range r {
+		if i == 0 || r[i-1] == ' ' || r[i-1] == '-' {
+			r[i] = unicode.ToUpper(r[i])
+		}
+	}
+	return string(r)
+}
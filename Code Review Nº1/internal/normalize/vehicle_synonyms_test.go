@@ -0,0 +1,102 @@
+package normalize_test
+
+import (
+	"Code_Review_N_1/internal"
+	"Code_Review_N_1/internal/normalize"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestVehicleSynonyms_Normalize(t *testing.T) {
+	nz := normalize.NewVehicleSynonyms(normalize.DefaultSynonyms())
+
+	tests := []struct {
+		name string
+		in   internal.VehicleAttributes
+		want internal.VehicleCategories
+	}{
+		{
+			name: "default synonyms",
+			in:   internal.VehicleAttributes{Brand: "chevy", Color: "grey", FuelType: "petrol", Transmission: "auto"},
+			want: internal.VehicleCategories{Brand: "Chevrolet", Color: "Gray", FuelType: "gasoline", Transmission: "automatic"},
+		},
+		{
+			name: "case folded and spaces collapsed",
+			in:   internal.VehicleAttributes{Brand: " VW ", Color: "FUSCIA", FuelType: "GAS", Transmission: "Semi   Automatic"},
+			want: internal.VehicleCategories{Brand: "Volkswagen", Color: "Fuchsia", FuelType: "gasoline", Transmission: "semi-automatic"},
+		},
+		{
+			name: "unknown values pass through in their canonical casing",
+			in:   internal.VehicleAttributes{Brand: "aston  MARTIN", Color: "dark-blue", FuelType: "Diesel", Transmission: "Manual"},
+			want: internal.VehicleCategories{Brand: "Aston Martin", Color: "Dark-Blue", FuelType: "diesel", Transmission: "manual"},
+		},
+		{
+			name: "canonical values are kept",
+			in:   internal.VehicleAttributes{Brand: "Mercedes-Benz", Color: "Gray", FuelType: "gasoline", Transmission: "semi-automatic"},
+			want: internal.VehicleCategories{Brand: "Mercedes-Benz", Color: "Gray", FuelType: "gasoline", Transmission: "semi-automatic"},
+		},
+		{
+			name: "empty",
+			in:   internal.VehicleAttributes{},
+			want: internal.VehicleCategories{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := nz.Normalize(tt.in)
+
+			if got != tt.want {
+				t.Fatalf("Normalize() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadSynonyms(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "synonyms.json")
+	err := os.WriteFile(path, []byte(`{"brand":{"Merc":"Mercedes-Benz","chevy":"Chevy"},"color":{"navy":"Blue"}}`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := normalize.LoadSynonyms(path)
+	if err != nil {
+		t.Fatalf("LoadSynonyms() error = %v", err)
+	}
+	nz := normalize.NewVehicleSynonyms(s)
+
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{name: "custom key matched case-insensitively", got: nz.Brand("MERC"), want: "Mercedes-Benz"},
+		{name: "custom synonym overrides the default", got: nz.Brand("chevy"), want: "Chevy"},
+		{name: "default synonym kept", got: nz.Brand("vw"), want: "Volkswagen"},
+		{name: "custom color", got: nz.Color("Navy"), want: "Blue"},
+		{name: "category without custom synonyms", got: nz.FuelType("petrol"), want: "gasoline"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Fatalf("got %q, want %q", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadSynonyms_Errors(t *testing.T) {
+	invalid := filepath.Join(t.TempDir(), "synonyms.json")
+	if err := os.WriteFile(invalid, []byte(`{"brand":`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{filepath.Join(t.TempDir(), "missing.json"), invalid} {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			if _, err := normalize.LoadSynonyms(path); err == nil {
+				t.Fatalf("LoadSynonyms(%q) error = nil, want an error", path)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"Code_Review_N_1/internal"
+	"context"
+	"sync"
+)
+
+// NewVehicleAuditSlice returns a new instance of a vehicle audit log in an slice.
+func NewVehicleAuditSlice() *VehicleAuditSlice {
+	return &VehicleAuditSlice{}
+}
+
+// VehicleAuditSlice is an struct that represents a vehicle audit log in an slice.
+type VehicleAuditSlice struct {
+	// mu guards the fields below.
+	mu sync.RWMutex
+	// db is the log of entries, oldest first.
+	db []internal.VehicleAuditEntry
+	// lastId is the last id of the log.
+	lastId int
+}
+
+// Add stores an entry, assigning its id
+func (s *VehicleAuditSlice) Add(ctx context.Context, e *internal.VehicleAuditEntry) (err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastId++
+	e.ID = s.lastId
+	s.db = append(s.db, *e)
+	return
+}
+
+// FindAll returns all entries, newest first
+func (s *VehicleAuditSlice) FindAll(ctx context.Context) (e []internal.VehicleAuditEntry, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	e = make([]internal.VehicleAuditEntry, len(s.db))
+	for i := range s.db {
+		e[i] = s.db[len(s.db)-1-i]
+	}
+	return
+}
@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"Code_Review_N_1/internal"
+	"context"
+	"errors"
+)
+
+// NewVehicleIndexed returns a new instance of a vehicle repository that keeps ix in sync with rp.
+// - ix is filled with the vehicles already stored in rp
+func NewVehicleIndexed(ctx context.Context, rp internal.RepositoryVehicle, ix internal.VehicleIndex) (r *VehicleIndexed, err error) {
+	r = &VehicleIndexed{rp: rp, ix: ix}
+	err = r.reindex(ctx)
+	return
+}
+
+// VehicleIndexed is an struct that represents a vehicle repository decorator that indexes every mutation.
+type VehicleIndexed struct {
+	// rp is the decorated repository.
+	rp internal.RepositoryVehicle
+	// ix is the index kept in sync.
+	ix internal.VehicleIndex
+}
+
+// reindex indexes all the vehicles of the repository.
+func (r *VehicleIndexed) reindex(ctx context.Context) (err error) {
+	vehicles, err := r.rp.FindAll(ctx)
+	if err != nil {
+		if errors.Is(err, internal.ErrRepositoryVehicleNotFound) {
+			err = nil
+		}
+		return
+	}
+	for _, v := range vehicles {
+		r.ix.Index(v)
+	}
+	return
+}
+
+// FindAll returns all vehicles
+func (r *VehicleIndexed) FindAll(ctx context.Context) (v []internal.Vehicle, err error) {
+	return r.rp.FindAll(ctx)
+}
+
+// FindByID returns the vehicle with the given id
+func (r *VehicleIndexed) FindByID(ctx context.Context, id int) (v internal.Vehicle, err error) {
+	return r.rp.FindByID(ctx, id)
+}
+
+// NextID reserves and returns the id of a new vehicle, never used before
+func (r *VehicleIndexed) NextID(ctx context.Context) (id int, err error) {
+	return r.rp.NextID(ctx)
+}
+
+func (r *VehicleIndexed) AddVehicle(ctx context.Context, newVehicle internal.Vehicle) error {
+	if err := r.rp.AddVehicle(ctx, newVehicle); err != nil {
+		return err
+	}
+	r.ix.Index(newVehicle)
+	return nil
+}
+
+func (r *VehicleIndexed) AddMultipleVehicles(ctx context.Context, newVehicles []internal.Vehicle) error {
+	if err := r.rp.AddMultipleVehicles(ctx, newVehicles); err != nil {
+		// some vehicles may have been added, index whatever is stored now
+		_ = r.reindex(context.WithoutCancel(ctx))
+		return err
+	}
+	for _, v := range newVehicles {
+		r.ix.Index(v)
+	}
+	return nil
+}
+
+func (r *VehicleIndexed) UpdateMaxSpeed(ctx context.Context, id int, newMaxSpeed int) error {
+	if err := r.rp.UpdateMaxSpeed(ctx, id, newMaxSpeed); err != nil {
+		return err
+	}
+	// keep the stored copy of the vehicle fresh for the results
+	v, err := r.rp.FindByID(context.WithoutCancel(ctx), id)
+	if err != nil {
+		return err
+	}
+	r.ix.Index(v)
+	return nil
+}
+
+func (r *VehicleIndexed) DeleteByID(ctx context.Context, id int) error {
+	if err := r.rp.DeleteByID(ctx, id); err != nil {
+		return err
+	}
+	r.ix.Remove(id)
+	return nil
+}
+
+func (r *VehicleIndexed) UpdateWhere(ctx context.Context, match func(internal.Vehicle) bool, update func(internal.Vehicle) internal.Vehicle) ([]internal.Vehicle, error) {
+	updated, err := r.rp.UpdateWhere(ctx, match, update)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range updated {
+		r.ix.Index(v)
+	}
+	return updated, nil
+}
+
+func (r *VehicleIndexed) DeleteWhere(ctx context.Context, match func(internal.Vehicle) bool) ([]internal.Vehicle, error) {
+	deleted, err := r.rp.DeleteWhere(ctx, match)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range deleted {
+		r.ix.Remove(v.ID)
+	}
+	return deleted, nil
+}
+
+// committer is the interface of the transactions that run hooks on commit.
+type committer interface {
+	// OnCommit registers fn to run when the transaction commits.
+	OnCommit(fn func())
+}
+
+// WithTx runs fn in a transaction of the decorated repository
+// - the changes made through tx are indexed when the transaction is committed,
+// inside its commit step if the transaction supports hooks, so racing transactions index in commit order
+func (r *VehicleIndexed) WithTx(ctx context.Context, fn func(tx internal.RepositoryVehicle) error) error {
+	var pending *vehicleIndexPending
+	err := r.rp.WithTx(ctx, func(tx internal.RepositoryVehicle) error {
+		pending = &vehicleIndexPending{ix: r.ix}
+		if c, ok := tx.(committer); ok {
+			c.OnCommit(pending.apply)
+			pending.hooked = true
+		}
+		return fn(&VehicleIndexed{rp: tx, ix: pending})
+	})
+	if err != nil {
+		return err
+	}
+	if !pending.hooked {
+		pending.apply()
+	}
+	return nil
+}
+
+// vehicleIndexPending is an struct that represents the changes of a transaction to an index, applied on commit.
+// - searches see the index without the pending changes
+type vehicleIndexPending struct {
+	// ix is the index the changes are applied to.
+	ix internal.VehicleIndex
+	// ops are the pending changes, in order.
+	ops []func()
+	// hooked tells whether the changes are applied by the commit hook of the transaction.
+	hooked bool
+}
+
+// Index adds or replaces a vehicle in the index on commit
+func (p *vehicleIndexPending) Index(v internal.Vehicle) {
+	p.ops = append(p.ops, func() { p.ix.Index(v) })
+}
+
+// Remove removes a vehicle from the index on commit
+func (p *vehicleIndexPending) Remove(id int) {
+	p.ops = append(p.ops, func() { p.ix.Remove(id) })
+}
+
+// Search returns the vehicles matching the query in the committed index
+func (p *vehicleIndexPending) Search(query string, limit int) []internal.VehicleMatch {
+	return p.ix.Search(query, limit)
+}
+
+// apply applies the pending changes to the index.
+func (p *vehicleIndexPending) apply() {
+	for _, op := range p.ops {
+		op()
+	}
+}
@@ -0,0 +1,77 @@
+package repository_test
+
+import (
+	"Code_Review_N_1/internal"
+	"Code_Review_N_1/internal/normalize"
+	"Code_Review_N_1/internal/repository"
+	"Code_Review_N_1/internal/search"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// slowIndex is an index that takes a while to index a vehicle, to widen the window of racing writers.
+type slowIndex struct {
+	*search.VehicleInverted
+}
+
+func (x slowIndex) Index(v internal.Vehicle) {
+	time.Sleep(time.Duration(v.Attributes.MaxSpeed%3) * time.Millisecond)
+	x.VehicleInverted.Index(v)
+}
+
+func TestVehicleIndexed_WithTxIndexesInCommitOrder(t *testing.T) {
+	rp := repository.NewVehicleSlice(vehicles(1), 1)
+	ix := search.NewVehicleInverted(normalize.NewVehicleSynonyms(normalize.DefaultSynonyms()))
+	r, err := repository.NewVehicleIndexed(context.Background(), rp, slowIndex{ix})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 1; i <= 50; i++ {
+		wg.Add(1)
+		go func(speed int) {
+			defer wg.Done()
+			_ = r.WithTx(context.Background(), func(tx internal.RepositoryVehicle) error {
+				return tx.UpdateMaxSpeed(context.Background(), 1, speed)
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	stored, err := rp.FindByID(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := ix.Search("ford", 0)
+	if len(m) != 1 || m[0].Vehicle.Attributes.MaxSpeed != stored.Attributes.MaxSpeed {
+		t.Fatalf("indexed = %+v, want max speed %d", m, stored.Attributes.MaxSpeed)
+	}
+}
+
+func TestVehicleIndexed_WithTxRollback(t *testing.T) {
+	rp := repository.NewVehicleSlice(vehicles(1), 1)
+	ix := search.NewVehicleInverted(normalize.NewVehicleSynonyms(normalize.DefaultSynonyms()))
+	r, err := repository.NewVehicleIndexed(context.Background(), rp, ix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	errFail := errors.New("fail")
+
+	err = r.WithTx(context.Background(), func(tx internal.RepositoryVehicle) error {
+		if err := tx.DeleteByID(context.Background(), 1); err != nil {
+			return err
+		}
+		return errFail
+	})
+
+	if !errors.Is(err, errFail) {
+		t.Fatalf("err = %v, want %v", err, errFail)
+	}
+	if m := ix.Search("ford", 0); len(m) != 1 {
+		t.Fatalf("indexed = %+v, want vehicle 1", m)
+	}
+}
@@ -1,25 +1,56 @@
 package repository
 
-import "Code_Review_N_1/internal"
+import (
+	"Code_Review_N_1/internal"
+	"context"
+	"sync"
+)
 
 // NewVehicleSlice returns a new instance of a vehicle repository in an slice.
 func NewVehicleSlice(db []internal.Vehicle, lastId int) *VehicleSlice {
 	return &VehicleSlice{
+		mu:     &sync.RWMutex{},
 		db:     db,
 		lastId: lastId,
 	}
 }
 
+// rwLocker is the interface that wraps the methods of a reader/writer lock.
+type rwLocker interface {
+	sync.Locker
+	RLock()
+	RUnlock()
+}
+
+// noLock is an struct that represents the lock of a transaction, already guarded by the lock of its repository.
+type noLock struct{}
+
+func (noLock) Lock()    {}
+func (noLock) Unlock()  {}
+func (noLock) RLock()   {}
+func (noLock) RUnlock() {}
+
 // VehicleSlice is an struct that represents a vehicle repository in an slice.
 type VehicleSlice struct {
+	// mu guards db.
+	mu rwLocker
 	// db is the database of vehicles.
 	db []internal.Vehicle
 	// lastId is the last id of the database.
 	lastId int
+	// onCommit are the hooks run on commit, nil outside a transaction.
+	onCommit *[]func()
 }
 
 // FindAll returns all vehicles
-func (s *VehicleSlice) FindAll() (v []internal.Vehicle, err error) {
+func (s *VehicleSlice) FindAll(ctx context.Context) (v []internal.Vehicle, err error) {
+	// check if the request was cancelled
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	// check if the database is empty
 	if len(s.db) == 0 {
 		err = internal.ErrRepositoryVehicleNotFound
@@ -32,22 +63,71 @@ func (s *VehicleSlice) FindAll() (v []internal.Vehicle, err error) {
 	return
 }
 
-func (s *VehicleSlice) AddVehicle(newVehicles internal.Vehicle) error {
+// FindByID returns the vehicle with the given id
+func (s *VehicleSlice) FindByID(ctx context.Context, id int) (v internal.Vehicle, err error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, vehicle := range s.db {
+		if err = ctx.Err(); err != nil {
+			return
+		}
+		if vehicle.ID == id {
+			v = vehicle
+			return
+		}
+	}
+	err = internal.ErrRepositoryVehicleNotFound
+	return
+}
+
+// NextID reserves and returns the id of a new vehicle, never used before
+func (s *VehicleSlice) NextID(ctx context.Context) (id int, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastId++
+	id = s.lastId
+	return
+}
+
+func (s *VehicleSlice) AddVehicle(ctx context.Context, newVehicles internal.Vehicle) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	s.db = append(s.db, newVehicles)
+	s.lastId = max(s.lastId, newVehicles.ID)
 	return nil
 }
 
-func (s *VehicleSlice) AddMultipleVehicles(newVehicles []internal.Vehicle) error {
-	for _, vehicle := range newVehicles {
-		if err := s.AddVehicle(vehicle); err != nil {
-			return err
-		}
+func (s *VehicleSlice) AddMultipleVehicles(ctx context.Context, newVehicles []internal.Vehicle) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.db = append(s.db, newVehicles...)
+	for _, v := range newVehicles {
+		s.lastId = max(s.lastId, v.ID)
 	}
 	return nil
 }
 
-func (s *VehicleSlice) UpdateMaxSpeed(id int, newMaxSpeed int) error {
+func (s *VehicleSlice) UpdateMaxSpeed(ctx context.Context, id int, newMaxSpeed int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	for i, vehicle := range s.db {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		if vehicle.ID == id {
 			s.db[i].Attributes.MaxSpeed = newMaxSpeed
 			return nil
@@ -55,9 +135,16 @@ func (s *VehicleSlice) UpdateMaxSpeed(id int, newMaxSpeed int) error {
 	}
 	return internal.ErrRepositoryVehicleNotFound
 }
-func (s *VehicleSlice) DeleteByID(id int) error {
+
+func (s *VehicleSlice) DeleteByID(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	index := -1
 	for i, vehicle := range s.db {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		if vehicle.ID == id {
 			index = i
 			break
@@ -70,3 +157,93 @@ func (s *VehicleSlice) DeleteByID(id int) error {
 
 	return nil
 }
+
+// UpdateWhere replaces every vehicle matching match with the result of update
+// - the database is only changed when every vehicle was processed
+func (s *VehicleSlice) UpdateWhere(ctx context.Context, match func(internal.Vehicle) bool, update func(internal.Vehicle) internal.Vehicle) (updated []internal.Vehicle, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// build the new database
+	db := make([]internal.Vehicle, len(s.db))
+	for i, vehicle := range s.db {
+		if err = ctx.Err(); err != nil {
+			return nil, err
+		}
+		if match(vehicle) {
+			vehicle = update(vehicle)
+			updated = append(updated, vehicle)
+		}
+		db[i] = vehicle
+	}
+
+	// commit
+	s.db = db
+	return
+}
+
+// DeleteWhere removes every vehicle matching match
+// - the database is only changed when every vehicle was processed
+func (s *VehicleSlice) DeleteWhere(ctx context.Context, match func(internal.Vehicle) bool) (deleted []internal.Vehicle, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// build the new database
+	db := make([]internal.Vehicle, 0, len(s.db))
+	for _, vehicle := range s.db {
+		if err = ctx.Err(); err != nil {
+			return nil, err
+		}
+		if match(vehicle) {
+			deleted = append(deleted, vehicle)
+			continue
+		}
+		db = append(db, vehicle)
+	}
+
+	// commit
+	s.db = db
+	return
+}
+
+// WithTx runs fn in a transaction over a copy of the database
+// - the copy replaces the database when fn returns nil and is discarded otherwise
+// - the database is locked meanwhile, so transactions are serialized with every other operation
+func (s *VehicleSlice) WithTx(ctx context.Context, fn func(tx internal.RepositoryVehicle) error) (err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// begin
+	var hooks []func()
+	tx := &VehicleSlice{
+		mu:       noLock{},
+		db:       append([]internal.Vehicle(nil), s.db...),
+		lastId:   s.lastId,
+		onCommit: &hooks,
+	}
+
+	// rollback
+	if err = fn(tx); err != nil {
+		return
+	}
+
+	// commit
+	s.db, s.lastId = tx.db, tx.lastId
+	for _, hook := range hooks {
+		hook()
+	}
+	return
+}
+
+// OnCommit registers fn to run when the transaction commits, while the database is still locked
+// - outside a transaction fn runs right away
+func (s *VehicleSlice) OnCommit(fn func()) {
+	if s.onCommit == nil {
+		fn()
+		return
+	}
+	*s.onCommit = append(*s.onCommit, fn)
+}
@@ -0,0 +1,72 @@
+package repository_test
+
+import (
+	"Code_Review_N_1/internal"
+	"Code_Review_N_1/internal/repository"
+	"context"
+	"errors"
+	"testing"
+)
+
+// vehicles returns n vehicles with ids from 1.
+func vehicles(n int) []internal.Vehicle {
+	v := make([]internal.Vehicle, n)
+	for i := range v {
+		v[i] = internal.Vehicle{ID: i + 1, Attributes: internal.VehicleAttributes{Brand: "Ford", Color: "Red", Year: 2000}}
+	}
+	return v
+}
+
+// cancelledAfter is a context whose Err reports context.Canceled after n calls, to cancel in the middle of a scan.
+type cancelledAfter struct {
+	context.Context
+	n int
+}
+
+func (c *cancelledAfter) Err() error {
+	if c.n <= 0 {
+		return context.Canceled
+	}
+	c.n--
+	return nil
+}
+
+func TestVehicleSlice_CancelledContext(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name string
+		call func(rp *repository.VehicleSlice) error
+	}{
+		{"FindAll", func(rp *repository.VehicleSlice) error {
+			_, err := rp.FindAll(cancelled)
+			return err
+		}},
+		{"FindByID", func(rp *repository.VehicleSlice) error {
+			_, err := rp.FindByID(cancelled, 1000)
+			return err
+		}},
+		{"FindByID mid scan", func(rp *repository.VehicleSlice) error {
+			_, err := rp.FindByID(&cancelledAfter{Context: context.Background(), n: 10}, 1000)
+			return err
+		}},
+		{"UpdateMaxSpeed mid scan", func(rp *repository.VehicleSlice) error {
+			return rp.UpdateMaxSpeed(&cancelledAfter{Context: context.Background(), n: 10}, 1000, 100)
+		}},
+		{"AddVehicle", func(rp *repository.VehicleSlice) error {
+			return rp.AddVehicle(cancelled, internal.Vehicle{ID: 1001})
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rp := repository.NewVehicleSlice(vehicles(1000), 1000)
+
+			err := tt.call(rp)
+
+			if !errors.Is(err, context.Canceled) {
+				t.Fatalf("err = %v, want %v", err, context.Canceled)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"Code_Review_N_1/internal"
+	"context"
+	"sort"
+	"sync"
+)
+
+// NewWebhookMap returns a new instance of a webhook repository in a map.
+// - logSize is the number of attempts kept in the delivery log of every webhook
+func NewWebhookMap(logSize int) *WebhookMap {
+	return &WebhookMap{
+		db:          make(map[int]internal.Webhook),
+		attempts:    make(map[int][]internal.WebhookAttempt),
+		deadLetters: make(map[int]internal.WebhookDeadLetter),
+		logSize:     logSize,
+	}
+}
+
+// WebhookMap is an struct that represents a webhook repository in a map.
+type WebhookMap struct {
+	// mu guards the fields below.
+	mu sync.RWMutex
+	// db is the database of webhooks.
+	db map[int]internal.Webhook
+	// lastId is the last id of the database.
+	lastId int
+	// attempts are the delivery logs by webhook, oldest first.
+	attempts map[int][]internal.WebhookAttempt
+	// logSize is the number of attempts kept by webhook.
+	logSize int
+	// deadLetters are the deliveries that exhausted their retries.
+	deadLetters map[int]internal.WebhookDeadLetter
+	// lastDeadLetterId is the last id of the dead letters.
+	lastDeadLetterId int
+}
+
+// FindAll returns all webhooks
+func (m *WebhookMap) FindAll(ctx context.Context) (w []internal.Webhook, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	w = make([]internal.Webhook, 0, len(m.db))
+	for _, webhook := range m.db {
+		w = append(w, webhook)
+	}
+	sort.Slice(w, func(i, j int) bool { return w[i].ID < w[j].ID })
+	return
+}
+
+// FindByID returns the webhook with the given id
+func (m *WebhookMap) FindByID(ctx context.Context, id int) (w internal.Webhook, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	w, ok := m.db[id]
+	if !ok {
+		err = internal.ErrRepositoryWebhookNotFound
+		return
+	}
+	return
+}
+
+// Save stores a new webhook, assigning its id
+func (m *WebhookMap) Save(ctx context.Context, w *internal.Webhook) (err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.lastId++
+	w.ID = m.lastId
+	m.db[w.ID] = *w
+	return
+}
+
+// DeleteByID removes a webhook and its delivery log
+func (m *WebhookMap) DeleteByID(ctx context.Context, id int) (err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.db[id]; !ok {
+		err = internal.ErrRepositoryWebhookNotFound
+		return
+	}
+	delete(m.db, id)
+	delete(m.attempts, id)
+	return
+}
+
+// AddAttempt appends an attempt to the delivery log of its webhook
+func (m *WebhookMap) AddAttempt(ctx context.Context, a internal.WebhookAttempt) (err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.db[a.WebhookID]; !ok {
+		err = internal.ErrRepositoryWebhookNotFound
+		return
+	}
+	log := append(m.attempts[a.WebhookID], a)
+	if len(log) > m.logSize {
+		log = log[len(log)-m.logSize:]
+	}
+	m.attempts[a.WebhookID] = log
+	return
+}
+
+// FindAttempts returns the delivery log of a webhook, newest first
+func (m *WebhookMap) FindAttempts(ctx context.Context, webhookID int) (a []internal.WebhookAttempt, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if _, ok := m.db[webhookID]; !ok {
+		err = internal.ErrRepositoryWebhookNotFound
+		return
+	}
+	log := m.attempts[webhookID]
+	a = make([]internal.WebhookAttempt, len(log))
+	for i := range log {
+		a[i] = log[len(log)-1-i]
+	}
+	return
+}
+
+// AddDeadLetter stores a delivery that exhausted its retries, assigning its id
+func (m *WebhookMap) AddDeadLetter(ctx context.Context, d *internal.WebhookDeadLetter) (err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.lastDeadLetterId++
+	d.ID = m.lastDeadLetterId
+	m.deadLetters[d.ID] = *d
+	return
+}
+
+// FindDeadLetters returns all dead letters
+func (m *WebhookMap) FindDeadLetters(ctx context.Context) (d []internal.WebhookDeadLetter, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	d = make([]internal.WebhookDeadLetter, 0, len(m.deadLetters))
+	for _, letter := range m.deadLetters {
+		d = append(d, letter)
+	}
+	sort.Slice(d, func(i, j int) bool { return d[i].ID < d[j].ID })
+	return
+}
+
+// TakeDeadLetter removes and returns a dead letter
+func (m *WebhookMap) TakeDeadLetter(ctx context.Context, id int) (d internal.WebhookDeadLetter, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	d, ok := m.deadLetters[id]
+	if !ok {
+		err = internal.ErrRepositoryDeadLetterNotFound
+		return
+	}
+	delete(m.deadLetters, id)
+	return
+}
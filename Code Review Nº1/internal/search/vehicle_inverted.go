@@ -0,0 +1,264 @@
+package search
+
+import (
+	"Code_Review_N_1/internal"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+const (
+	// prefixScore is the score of a query term that is a prefix of an indexed term.
+	prefixScore = 0.8
+	// editScore is the score lost by every edit between a query term and an indexed term.
+	editScore = 0.3
+)
+
+// fieldWeights are the weights of the indexed fields.
+var fieldWeights = map[string]float64{
+	"brand":        3,
+	"model":        3,
+	"registration": 2,
+	"color":        1,
+}
+
+// NewVehicleInverted returns a new instance of an in-memory inverted index of vehicles.
+// - brands and colors are indexed and searched by their canonical form of nz
+func NewVehicleInverted(nz internal.VehicleNormalizer) *VehicleInverted {
+	return &VehicleInverted{
+		nz:       nz,
+		docs:     make(map[int]internal.Vehicle),
+		postings: make(map[string]map[int]float64),
+		terms:    make(map[int][]string),
+	}
+}
+
+// VehicleInverted is an struct that represents an in-memory inverted index of vehicles.
+// - brand, model, color and registration are tokenized and case folded, brands and colors normalized
+// - query terms match by themselves or by their canonical brand or color (chevy -> chevrolet)
+// - query terms match indexed terms exactly, by prefix or within an edit distance (typos)
+// - vehicles are ranked by matched query terms and then by a tf-idf like score
+type VehicleInverted struct {
+	// nz normalizes the brands and colors.
+	nz internal.VehicleNormalizer
+	// mu guards the fields below.
+	mu sync.RWMutex
+	// docs are the indexed vehicles by id.
+	docs map[int]internal.Vehicle
+	// postings are the weights of every term by vehicle id.
+	postings map[string]map[int]float64
+	// terms are the terms of every vehicle, to remove it.
+	terms map[int][]string
+}
+
+// Index adds or replaces a vehicle in the index.
+func (x *VehicleInverted) Index(v internal.Vehicle) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	x.remove(v.ID)
+	x.docs[v.ID] = v
+
+	fields := map[string]string{
+		"brand":        x.nz.Brand(v.Attributes.Brand),
+		"model":        v.Attributes.Model,
+		"registration": v.Attributes.Registration,
+		"color":        x.nz.Color(v.Attributes.Color),
+	}
+	for field, value := range fields {
+		for _, term := range Tokenize(value) {
+			p, ok := x.postings[term]
+			if !ok {
+				p = make(map[int]float64)
+				x.postings[term] = p
+			}
+			if _, ok := p[v.ID]; !ok {
+				x.terms[v.ID] = append(x.terms[v.ID], term)
+			}
+			p[v.ID] += fieldWeights[field]
+		}
+	}
+}
+
+// Remove removes a vehicle from the index.
+func (x *VehicleInverted) Remove(id int) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	x.remove(id)
+}
+
+// remove removes a vehicle from the index, the caller holds the lock.
+func (x *VehicleInverted) remove(id int) {
+	for _, term := range x.terms[id] {
+		delete(x.postings[term], id)
+		if len(x.postings[term]) == 0 {
+			delete(x.postings, term)
+		}
+	}
+	delete(x.terms, id)
+	delete(x.docs, id)
+}
+
+// Search returns the vehicles matching the query, best first.
+func (x *VehicleInverted) Search(query string, limit int) (m []internal.VehicleMatch) {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	type hit struct {
+		matched int
+		score   float64
+	}
+	hits := make(map[int]*hit)
+	n := float64(len(x.docs))
+
+	for _, q := range Tokenize(query) {
+		// best score of the query term, in any of its forms, for every vehicle
+		forms := x.forms(q)
+		best := make(map[int]float64)
+		for term, p := range x.postings {
+			var s float64
+			for _, f := range forms {
+				s = max(s, matchScore(f, term))
+			}
+			if s == 0 {
+				continue
+			}
+			idf := math.Log(1 + n/float64(len(p)))
+			for id, w := range p {
+				if v := s * w * idf; v > best[id] {
+					best[id] = v
+				}
+			}
+		}
+		for id, s := range best {
+			h, ok := hits[id]
+			if !ok {
+				h = &hit{}
+				hits[id] = h
+			}
+			h.matched++
+			h.score += s
+		}
+	}
+
+	// rank
+	m = make([]internal.VehicleMatch, 0, len(hits))
+	for id, h := range hits {
+		m = append(m, internal.VehicleMatch{Vehicle: x.docs[id], Matched: h.matched, Score: h.score})
+	}
+	sort.Slice(m, func(i, j int) bool {
+		if m[i].Matched != m[j].Matched {
+			return m[i].Matched > m[j].Matched
+		}
+		if m[i].Score != m[j].Score {
+			return m[i].Score > m[j].Score
+		}
+		return m[i].Vehicle.ID < m[j].Vehicle.ID
+	})
+	if limit > 0 && len(m) > limit {
+		m = m[:limit]
+	}
+	return
+}
+
+// forms returns the query term q and the terms of its canonical brand and color.
+func (x *VehicleInverted) forms(q string) (forms []string) {
+	forms = []string{q}
+	for _, canonical := range []string{x.nz.Brand(q), x.nz.Color(q)} {
+		for _, term := range Tokenize(canonical) {
+			if !slices.Contains(forms, term) {
+				forms = append(forms, term)
+			}
+		}
+	}
+	return
+}
+
+// Tokenize splits a text in case folded terms of letters and digits.
+func Tokenize(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// matchScore returns how well a query term matches an indexed term, zero when it does not.
+func matchScore(q, term string) float64 {
+	if q == term {
+		return 1
+	}
+	if len(q) >= 2 && strings.HasPrefix(term, q) {
+		return prefixScore
+	}
+
+	// typos, only for words long enough to tell them apart
+	edits := maxEdits(q)
+	if edits == 0 || isNumber(q) {
+		return 0
+	}
+	if d := distance(q, term, edits); d <= edits {
+		return 1 - editScore*float64(d)
+	}
+	return 0
+}
+
+// maxEdits returns the edits tolerated for a query term of the length of q.
+func maxEdits(q string) int {
+	switch n := len([]rune(q)); {
+	case n < 4:
+		return 0
+	case n < 8:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// isNumber reports whether s only has digits.
+func isNumber(s string) bool {
+	for _, r := range s {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// distance returns the optimal string alignment distance between a and b (insertions,
+// deletions, substitutions and transpositions of adjacent runes), or limit+1 when it exceeds limit.
+func distance(a, b string, limit int) int {
+	ra, rb := []rune(a), []rune(b)
+	if d := len(ra) - len(rb); d > limit || -d > limit {
+		return limit + 1
+	}
+
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		rowMin := curr[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				curr[j] = min(curr[j], prev2[j-2]+1)
+			}
+			rowMin = min(rowMin, curr[j])
+		}
+		if rowMin > limit {
+			return limit + 1
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+	return prev[len(rb)]
+}
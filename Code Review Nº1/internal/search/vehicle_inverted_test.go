@@ -0,0 +1,161 @@
+package search
+
+import (
+	"Code_Review_N_1/internal"
+	"Code_Review_N_1/internal/normalize"
+	"slices"
+	"testing"
+)
+
+// newTestIndex returns an index with the default synonyms and the given vehicles.
+func newTestIndex(vehicles ...internal.Vehicle) *VehicleInverted {
+	x := NewVehicleInverted(normalize.NewVehicleSynonyms(normalize.DefaultSynonyms()))
+	for _, v := range vehicles {
+		x.Index(v)
+	}
+	return x
+}
+
+// vehicle returns a vehicle with the indexed attributes.
+func vehicle(id int, brand, model, color, registration string) internal.Vehicle {
+	return internal.Vehicle{ID: id, Attributes: internal.VehicleAttributes{Brand: brand, Model: model, Color: color, Registration: registration}}
+}
+
+// ids returns the ids of the matches, in order.
+func ids(m []internal.VehicleMatch) []int {
+	ids := make([]int, len(m))
+	for i := range m {
+		ids[i] = m[i].Vehicle.ID
+	}
+	return ids
+}
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{in: "", want: []string{}},
+		{in: "Ford", want: []string{"ford"}},
+		{in: "  Aston  MARTIN ", want: []string{"aston", "martin"}},
+		{in: "Mercedes-Benz", want: []string{"mercedes", "benz"}},
+		{in: "2020-01-31", want: []string{"2020", "01", "31"}},
+		{in: "Škoda, Octavia!", want: []string{"škoda", "octavia"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got := Tokenize(tt.in)
+
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("Tokenize(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		a, b  string
+		limit int
+		want  int
+	}{
+		{a: "ford", b: "ford", limit: 2, want: 0},
+		{a: "frod", b: "ford", limit: 2, want: 1},
+		{a: "fordd", b: "ford", limit: 2, want: 1},
+		{a: "fird", b: "ford", limit: 2, want: 1},
+		{a: "toyta", b: "toyota", limit: 2, want: 1},
+		// optimal string alignment does not edit a substring twice, unlike Damerau-Levenshtein (2)
+		{a: "ca", b: "abc", limit: 3, want: 3},
+		// beyond the limit
+		{a: "ford", b: "fiat", limit: 1, want: 2},
+		{a: "ka", b: "corolla", limit: 2, want: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+"/"+tt.b, func(t *testing.T) {
+			got := distance(tt.a, tt.b, tt.limit)
+
+			if got != tt.want {
+				t.Fatalf("distance(%q, %q, %d) = %d, want %d", tt.a, tt.b, tt.limit, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVehicleInverted_Search(t *testing.T) {
+	x := newTestIndex(
+		vehicle(1, "Toyota", "Corolla", "Red", "2020-01-01"),
+		vehicle(2, "Toyota", "Yaris", "Blue", "2020-01-02"),
+		vehicle(3, "Chevrolet", "Camaro", "Red", "2020-01-03"),
+		vehicle(4, "Ford", "Fiesta", "Grey", "2020-01-04"),
+		vehicle(5, "Ford", "Focus", "Red", "2020-01-05"),
+	)
+
+	tests := []struct {
+		name  string
+		query string
+		want  []int
+	}{
+		{name: "exact", query: "corolla", want: []int{1}},
+		{name: "case folded", query: "COROLLA", want: []int{1}},
+		{name: "prefix", query: "fie", want: []int{4}},
+		{name: "one typo", query: "toyta", want: []int{1, 2}},
+		{name: "transposition", query: "yrais", want: []int{2}},
+		{name: "too many typos", query: "tayata", want: []int{}},
+		{name: "no typos in short terms", query: "frd", want: []int{}},
+		{name: "brand synonym", query: "chevy", want: []int{3}},
+		{name: "color synonym", query: "gray", want: []int{4}},
+		// every registration shares the year and the month
+		{name: "registration", query: "2020-01-05", want: []int{5, 1, 2, 3, 4}},
+		// more matched terms first, then brand and model weigh more than color
+		{name: "ranking", query: "ford red", want: []int{5, 4, 1, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ids(x.Search(tt.query, 0))
+
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("Search(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVehicleInverted_SearchLimit(t *testing.T) {
+	x := newTestIndex(
+		vehicle(1, "Ford", "Ka", "Red", "2020-01-01"),
+		vehicle(2, "Ford", "Ka", "Red", "2020-01-02"),
+		vehicle(3, "Ford", "Ka", "Red", "2020-01-03"),
+	)
+
+	got := ids(x.Search("ford", 2))
+
+	if want := []int{1, 2}; !slices.Equal(got, want) {
+		t.Fatalf("Search() = %v, want %v", got, want)
+	}
+}
+
+func TestVehicleInverted_Reindex(t *testing.T) {
+	x := newTestIndex(
+		vehicle(1, "Ford", "Fiesta", "Red", "2020-01-01"),
+		vehicle(2, "Ford", "Focus", "Blue", "2020-01-02"),
+	)
+
+	// update
+	x.Index(vehicle(1, "Ford", "Mustang", "Red", "2020-01-01"))
+	if got := ids(x.Search("fiesta", 0)); len(got) != 0 {
+		t.Fatalf("Search(old model) = %v, want none", got)
+	}
+	m := x.Search("mustang", 0)
+	if got := ids(m); !slices.Equal(got, []int{1}) || m[0].Vehicle.Attributes.Model != "Mustang" {
+		t.Fatalf("Search(new model) = %+v, want the updated vehicle 1", m)
+	}
+
+	// delete
+	x.Remove(2)
+	if got := ids(x.Search("ford", 0)); !slices.Equal(got, []int{1}) {
+		t.Fatalf("Search() after delete = %v, want [1]", got)
+	}
+	if got := ids(x.Search("focus", 0)); len(got) != 0 {
+		t.Fatalf("Search(deleted model) = %v, want none", got)
+	}
+}
@@ -0,0 +1,21 @@
+package service
+
+import (
+	"Code_Review_N_1/internal"
+	"context"
+)
+
+// NewVehicleAudit returns a new instance of a vehicle audit service.
+func NewVehicleAudit(rp internal.RepositoryVehicleAudit) *VehicleAudit {
+	return &VehicleAudit{rp: rp}
+}
+
+// VehicleAudit is an struct that represents a vehicle audit service.
+type VehicleAudit struct {
+	rp internal.RepositoryVehicleAudit
+}
+
+// FindAll returns all entries, newest first.
+func (s *VehicleAudit) FindAll(ctx context.Context) ([]internal.VehicleAuditEntry, error) {
+	return s.rp.FindAll(ctx)
+}
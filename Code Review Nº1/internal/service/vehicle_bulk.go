@@ -0,0 +1,137 @@
+package service
+
+import (
+	"Code_Review_N_1/internal"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// matcher returns the function that reports whether a vehicle matches the filter.
+func (s *Default) matcher(f internal.VehicleFilter) (match func(internal.Vehicle) bool, err error) {
+	if f == (internal.VehicleFilter{}) {
+		err = fmt.Errorf("%w: filter must have at least one criterion", internal.ErrServiceVehicleBulkInvalid)
+		return
+	}
+	brand, color, fuelType, transmission := s.nz.Brand(f.Brand), s.nz.Color(f.Color), s.nz.FuelType(f.FuelType), s.nz.Transmission(f.Transmission)
+	match = func(v internal.Vehicle) bool {
+		return (f.Brand == "" || v.Normalized.Brand == brand) &&
+			(f.Color == "" || v.Normalized.Color == color) &&
+			(f.FuelType == "" || v.Normalized.FuelType == fuelType) &&
+			(f.Transmission == "" || v.Normalized.Transmission == transmission) &&
+			(f.YearFrom == 0 || v.Attributes.Year >= f.YearFrom) &&
+			(f.YearTo == 0 || v.Attributes.Year <= f.YearTo) &&
+			(f.MaxSpeedMin == 0 || v.Attributes.MaxSpeed >= f.MaxSpeedMin) &&
+			(f.MaxSpeedMax == 0 || v.Attributes.MaxSpeed <= f.MaxSpeedMax)
+	}
+	return
+}
+
+// matching returns the ids of the vehicles that match, without changing them.
+func (s *Default) matching(ctx context.Context, match func(internal.Vehicle) bool) (ids []int, err error) {
+	vehicles, err := s.rp.FindAll(ctx)
+	if err != nil && !errors.Is(err, internal.ErrRepositoryVehicleNotFound) {
+		return nil, err
+	}
+	ids = []int{}
+	for _, v := range vehicles {
+		if match(v) {
+			ids = append(ids, v.ID)
+		}
+	}
+	return ids, nil
+}
+
+// idsOf returns the ids of the vehicles.
+func idsOf(vehicles []internal.Vehicle) []int {
+	ids := make([]int, len(vehicles))
+	for i, v := range vehicles {
+		ids[i] = v.ID
+	}
+	return ids
+}
+
+// BulkUpdate applies the patch to every vehicle matching the filter and returns their ids.
+// - with dryRun nothing is changed
+// - the vehicles are changed at once and a single audit entry is recorded
+func (s *Default) BulkUpdate(ctx context.Context, f internal.VehicleFilter, p internal.VehiclePatch, dryRun bool) (ids []int, err error) {
+	// validate
+	match, err := s.matcher(f)
+	if err != nil {
+		return
+	}
+	switch {
+	case p == (internal.VehiclePatch{}):
+		err = fmt.Errorf("%w: patch must change at least one field", internal.ErrServiceVehicleBulkInvalid)
+	case p.MaxSpeed != nil && *p.MaxSpeed <= 0:
+		err = fmt.Errorf("%w: max_speed must be positive", internal.ErrServiceVehicleBulkInvalid)
+	case (p.Color != nil && *p.Color == "") || (p.FuelType != nil && *p.FuelType == "") || (p.Transmission != nil && *p.Transmission == ""):
+		err = fmt.Errorf("%w: patched fields can not be empty", internal.ErrServiceVehicleBulkInvalid)
+	}
+	if err != nil {
+		return
+	}
+	if dryRun {
+		return s.matching(ctx, match)
+	}
+
+	// apply
+	updated, err := s.rp.UpdateWhere(ctx, match, func(v internal.Vehicle) internal.Vehicle {
+		if p.MaxSpeed != nil {
+			v.Attributes.MaxSpeed = *p.MaxSpeed
+		}
+		if p.Color != nil {
+			v.Attributes.Color = *p.Color
+		}
+		if p.FuelType != nil {
+			v.Attributes.FuelType = *p.FuelType
+		}
+		if p.Transmission != nil {
+			v.Attributes.Transmission = *p.Transmission
+		}
+		v.Normalized = s.nz.Normalize(v.Attributes)
+		return v
+	})
+	if err != nil {
+		return
+	}
+	ids = idsOf(updated)
+
+	// audit
+	// - the changes are already committed, the entry must be recorded even if the request is gone
+	err = s.au.Add(context.WithoutCancel(ctx), &internal.VehicleAuditEntry{Time: time.Now(), Action: internal.VehicleAuditBulkUpdate, Filter: f, Patch: &p, IDs: ids})
+	for _, v := range updated {
+		s.bs.Publish(internal.VehicleEvent{Type: internal.VehicleEventUpdated, Vehicle: v})
+	}
+	return
+}
+
+// BulkDelete removes every vehicle matching the filter and returns their ids.
+// - with dryRun nothing is removed
+// - the vehicles are removed at once and a single audit entry is recorded
+func (s *Default) BulkDelete(ctx context.Context, f internal.VehicleFilter, dryRun bool) (ids []int, err error) {
+	// validate
+	match, err := s.matcher(f)
+	if err != nil {
+		return
+	}
+	if dryRun {
+		return s.matching(ctx, match)
+	}
+
+	// apply
+	deleted, err := s.rp.DeleteWhere(ctx, match)
+	if err != nil {
+		return
+	}
+	ids = idsOf(deleted)
+
+	// audit
+	// - the changes are already committed, the entry must be recorded even if the request is gone
+	err = s.au.Add(context.WithoutCancel(ctx), &internal.VehicleAuditEntry{Time: time.Now(), Action: internal.VehicleAuditBulkDelete, Filter: f, IDs: ids})
+	for _, v := range deleted {
+		s.bs.Publish(internal.VehicleEvent{Type: internal.VehicleEventDeleted, Vehicle: v})
+	}
+	return
+}
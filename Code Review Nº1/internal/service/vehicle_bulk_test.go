@@ -0,0 +1,134 @@
+package service
+
+import (
+	"Code_Review_N_1/internal"
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// newBulkDefault returns a vehicle service over a red Ford of 2000, a blue Ford of 2005 and a red Fiat of 2010.
+func newBulkDefault(t *testing.T) *Default {
+	t.Helper()
+	sv, rp := newTestDefault(0)
+	for i, a := range []internal.VehicleAttributes{
+		{Brand: "Ford", Color: "Red", Year: 2000, MaxSpeed: 150, FuelType: "gas", Transmission: "manual"},
+		{Brand: "Ford", Color: "Blue", Year: 2005, MaxSpeed: 170, FuelType: "diesel", Transmission: "manual"},
+		{Brand: "Fiat", Color: "Red", Year: 2010, MaxSpeed: 160, FuelType: "gas", Transmission: "auto"},
+	} {
+		if err := rp.AddVehicle(context.Background(), internal.Vehicle{ID: i + 1, Attributes: a, Normalized: sv.nz.Normalize(a)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return sv
+}
+
+func TestDefault_BulkUpdate(t *testing.T) {
+	speed, zero, empty := 200, 0, ""
+	red := internal.VehicleFilter{Color: "RED"}
+
+	tests := []struct {
+		name   string
+		filter internal.VehicleFilter
+		patch  internal.VehiclePatch
+		dryRun bool
+		want   []int
+		// changed tells whether the vehicles and the audit log change
+		changed bool
+		wantErr error
+	}{
+		{name: "apply", filter: red, patch: internal.VehiclePatch{MaxSpeed: &speed}, want: []int{1, 3}, changed: true},
+		{name: "dry run", filter: red, patch: internal.VehiclePatch{MaxSpeed: &speed}, dryRun: true, want: []int{1, 3}},
+		{name: "no match", filter: internal.VehicleFilter{Brand: "Seat"}, patch: internal.VehiclePatch{MaxSpeed: &speed}, want: []int{}, changed: true},
+		{name: "empty filter", patch: internal.VehiclePatch{MaxSpeed: &speed}, wantErr: internal.ErrServiceVehicleBulkInvalid},
+		{name: "empty patch", filter: red, wantErr: internal.ErrServiceVehicleBulkInvalid},
+		{name: "invalid max speed", filter: red, patch: internal.VehiclePatch{MaxSpeed: &zero}, wantErr: internal.ErrServiceVehicleBulkInvalid},
+		{name: "empty color", filter: red, patch: internal.VehiclePatch{MaxSpeed: &speed, Color: &empty}, wantErr: internal.ErrServiceVehicleBulkInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sv := newBulkDefault(t)
+			before, _ := sv.rp.FindAll(context.Background())
+
+			ids, err := sv.BulkUpdate(context.Background(), tt.filter, tt.patch, tt.dryRun)
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("BulkUpdate() error = %v, want %v", err, tt.wantErr)
+			}
+			if !slices.Equal(ids, tt.want) {
+				t.Fatalf("ids = %v, want %v", ids, tt.want)
+			}
+			after, _ := sv.rp.FindAll(context.Background())
+			entries, _ := sv.au.FindAll(context.Background())
+			if !tt.changed {
+				if !reflect.DeepEqual(after, before) {
+					t.Fatalf("vehicles = %+v, want them untouched %+v", after, before)
+				}
+				if len(entries) != 0 {
+					t.Fatalf("audit = %+v, want no entries", entries)
+				}
+				return
+			}
+			for _, v := range after {
+				if slices.Contains(tt.want, v.ID) != (v.Attributes.MaxSpeed == speed) {
+					t.Fatalf("vehicle %d max speed = %d, want %d only if matched", v.ID, v.Attributes.MaxSpeed, speed)
+				}
+			}
+			if len(entries) != 1 || entries[0].Action != internal.VehicleAuditBulkUpdate || !slices.Equal(entries[0].IDs, tt.want) ||
+				entries[0].Filter != tt.filter || entries[0].Patch == nil || *entries[0].Patch != tt.patch {
+				t.Fatalf("audit = %+v, want a single bulk update entry of %v", entries, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefault_BulkDelete(t *testing.T) {
+	fords := internal.VehicleFilter{Brand: "ford"}
+
+	tests := []struct {
+		name    string
+		filter  internal.VehicleFilter
+		dryRun  bool
+		want    []int
+		changed bool
+		wantErr error
+	}{
+		{name: "apply", filter: fords, want: []int{1, 2}, changed: true},
+		{name: "dry run", filter: fords, dryRun: true, want: []int{1, 2}},
+		{name: "empty filter", wantErr: internal.ErrServiceVehicleBulkInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sv := newBulkDefault(t)
+			before, _ := sv.rp.FindAll(context.Background())
+
+			ids, err := sv.BulkDelete(context.Background(), tt.filter, tt.dryRun)
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("BulkDelete() error = %v, want %v", err, tt.wantErr)
+			}
+			if !slices.Equal(ids, tt.want) {
+				t.Fatalf("ids = %v, want %v", ids, tt.want)
+			}
+			after, _ := sv.rp.FindAll(context.Background())
+			entries, _ := sv.au.FindAll(context.Background())
+			if !tt.changed {
+				if !reflect.DeepEqual(after, before) {
+					t.Fatalf("vehicles = %+v, want them untouched %+v", after, before)
+				}
+				if len(entries) != 0 {
+					t.Fatalf("audit = %+v, want no entries", entries)
+				}
+				return
+			}
+			if len(after) != len(before)-len(tt.want) {
+				t.Fatalf("%d vehicles left, want %d", len(after), len(before)-len(tt.want))
+			}
+			if len(entries) != 1 || entries[0].Action != internal.VehicleAuditBulkDelete || !slices.Equal(entries[0].IDs, tt.want) || entries[0].Patch != nil {
+				t.Fatalf("audit = %+v, want a single bulk delete entry of %v", entries, tt.want)
+			}
+		})
+	}
+}
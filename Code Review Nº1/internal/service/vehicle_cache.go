@@ -0,0 +1,232 @@
+package service
+
+import (
+	"Code_Review_N_1/internal"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// NewCache returns a new instance of a vehicle service that caches the queries of sv for ttl.
+// - nz normalizes the categorical arguments of the queries, so synonyms share an entry
+func NewCache(sv internal.ServiceVehicle, nz internal.VehicleNormalizer, ttl time.Duration) *Cache {
+	return &Cache{
+		sv:      sv,
+		nz:      nz,
+		ttl:     ttl,
+		entries: make(map[string]cacheEntry),
+	}
+}
+
+// cacheEntry is an struct that represents a cached query result.
+type cacheEntry struct {
+	// value is the result of the query.
+	value any
+	// err is the not found error of the query, if any.
+	err error
+	// expiresAt is the time when the entry stops being valid.
+	expiresAt time.Time
+}
+
+// Cache is an struct that represents a caching decorator of a vehicle service.
+// - queries are cached for ttl, including not found results
+// - every mutation empties the cache, before and after reaching the service, so a query
+// that raced with a write is never stored (see generation)
+type Cache struct {
+	// sv is the decorated service.
+	sv internal.ServiceVehicle
+	// nz is the normalizer of the arguments of the queries.
+	nz internal.VehicleNormalizer
+	// ttl is the time an entry is valid.
+	ttl time.Duration
+
+	// mu guards the fields below.
+	mu sync.Mutex
+	// entries are the cached results by query.
+	entries map[string]cacheEntry
+	// generation is incremented on every invalidation, results loaded in a previous generation are discarded.
+	generation uint64
+	// stats are the metrics of the cache.
+	stats internal.CacheStats
+}
+
+// cached returns the result of the query key from the cache, loading it with load when missing.
+func cached[T any](c *Cache, key string, load func() (T, error)) (v T, err error) {
+	// lookup
+	c.mu.Lock()
+	e, ok := c.entries[key]
+	if ok && time.Now().Before(e.expiresAt) {
+		c.stats.Hits++
+		c.mu.Unlock()
+		v, _ = e.value.(T)
+		return v, e.err
+	}
+	if ok {
+		delete(c.entries, key)
+	}
+	c.stats.Misses++
+	generation := c.generation
+	c.mu.Unlock()
+
+	// load
+	v, err = load()
+	if err != nil && !errors.Is(err, internal.ErrServiceVehicleNotFound) && !errors.Is(err, internal.ErrRepositoryVehicleNotFound) {
+		// do not cache failures such as a cancelled request
+		return
+	}
+
+	// store, unless a mutation happened meanwhile
+	c.mu.Lock()
+	if c.generation == generation {
+		c.entries[key] = cacheEntry{value: v, err: err, expiresAt: time.Now().Add(c.ttl)}
+	}
+	c.mu.Unlock()
+	return
+}
+
+// invalidate empties the cache.
+func (c *Cache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[string]cacheEntry)
+	c.generation++
+	c.stats.Invalidations++
+}
+
+// mutate runs a mutation of the service invalidating the cache around it.
+func (c *Cache) mutate(mutation func() error) error {
+	c.invalidate()
+	defer c.invalidate()
+	return mutation()
+}
+
+// copyVehicles returns a copy of vehicles, so callers can not modify the cached slice.
+func copyVehicles(vehicles []internal.Vehicle) []internal.Vehicle {
+	if vehicles == nil {
+		return nil
+	}
+	v := make([]internal.Vehicle, len(vehicles))
+	copy(v, vehicles)
+	return v
+}
+
+// Stats returns the current metrics of the cache.
+func (c *Cache) Stats() internal.CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	st := c.stats
+	st.Entries = len(c.entries)
+	return st
+}
+
+// FindAll returns all vehicles.
+func (c *Cache) FindAll(ctx context.Context) ([]internal.Vehicle, error) {
+	v, err := cached(c, "FindAll", func() ([]internal.Vehicle, error) {
+		return c.sv.FindAll(ctx)
+	})
+	return copyVehicles(v), err
+}
+
+func (c *Cache) FindByID(ctx context.Context, id int) (internal.Vehicle, error) {
+	return cached(c, fmt.Sprintf("FindByID:%d", id), func() (internal.Vehicle, error) {
+		return c.sv.FindByID(ctx, id)
+	})
+}
+
+func (c *Cache) AddVehicle(ctx context.Context, newVehicle internal.Vehicle) (v internal.Vehicle, err error) {
+	err = c.mutate(func() (err error) {
+		v, err = c.sv.AddVehicle(ctx, newVehicle)
+		return
+	})
+	return
+}
+
+func (c *Cache) ValidateVehicleFields(ctx context.Context, vehicle internal.Vehicle) error {
+	return c.sv.ValidateVehicleFields(ctx, vehicle)
+}
+
+func (c *Cache) ValidateDateFormat(ctx context.Context, date string) error {
+	return c.sv.ValidateDateFormat(ctx, date)
+}
+
+func (c *Cache) ValidateUniqueRegistration(ctx context.Context, registration string) error {
+	return c.sv.ValidateUniqueRegistration(ctx, registration)
+}
+
+func (c *Cache) FindByColorAndYear(ctx context.Context, color string, year int) ([]internal.Vehicle, error) {
+	v, err := cached(c, fmt.Sprintf("FindByColorAndYear:%q:%d", c.nz.Color(color), year), func() ([]internal.Vehicle, error) {
+		return c.sv.FindByColorAndYear(ctx, color, year)
+	})
+	return copyVehicles(v), err
+}
+
+func (c *Cache) FindByBrandAndYearRange(ctx context.Context, brand string, startYear, endYear int) ([]internal.Vehicle, error) {
+	v, err := cached(c, fmt.Sprintf("FindByBrandAndYearRange:%q:%d:%d", c.nz.Brand(brand), startYear, endYear), func() ([]internal.Vehicle, error) {
+		return c.sv.FindByBrandAndYearRange(ctx, brand, startYear, endYear)
+	})
+	return copyVehicles(v), err
+}
+
+func (c *Cache) GetAverageSpeedByBrand(ctx context.Context, brand string) (float64, error) {
+	return cached(c, fmt.Sprintf("GetAverageSpeedByBrand:%q", c.nz.Brand(brand)), func() (float64, error) {
+		return c.sv.GetAverageSpeedByBrand(ctx, brand)
+	})
+}
+
+func (c *Cache) AddMultipleVehicles(ctx context.Context, newVehicles []internal.Vehicle) (v []internal.Vehicle, err error) {
+	err = c.mutate(func() (err error) {
+		v, err = c.sv.AddMultipleVehicles(ctx, newVehicles)
+		return
+	})
+	return
+}
+
+func (c *Cache) UpdateMaxSpeed(ctx context.Context, id int, newMaxSpeed int) error {
+	return c.mutate(func() error {
+		return c.sv.UpdateMaxSpeed(ctx, id, newMaxSpeed)
+	})
+}
+
+func (c *Cache) FindByFuelType(ctx context.Context, fuelType string) ([]internal.Vehicle, error) {
+	v, err := cached(c, fmt.Sprintf("FindByFuelType:%q", c.nz.FuelType(fuelType)), func() ([]internal.Vehicle, error) {
+		return c.sv.FindByFuelType(ctx, fuelType)
+	})
+	return copyVehicles(v), err
+}
+
+func (c *Cache) DeleteVehicleByID(ctx context.Context, id int) error {
+	return c.mutate(func() error {
+		return c.sv.DeleteVehicleByID(ctx, id)
+	})
+}
+
+// FilterByMetrics is not cached, it filters vehicles already loaded.
+func (c *Cache) FilterByMetrics(ctx context.Context, vehicles []internal.Vehicle, q internal.VehicleMetricsQuery) ([]internal.Vehicle, error) {
+	return c.sv.FilterByMetrics(ctx, vehicles, q)
+}
+
+func (c *Cache) BulkUpdate(ctx context.Context, f internal.VehicleFilter, p internal.VehiclePatch, dryRun bool) (ids []int, err error) {
+	if dryRun {
+		return c.sv.BulkUpdate(ctx, f, p, dryRun)
+	}
+	err = c.mutate(func() (err error) {
+		ids, err = c.sv.BulkUpdate(ctx, f, p, dryRun)
+		return
+	})
+	return
+}
+
+func (c *Cache) BulkDelete(ctx context.Context, f internal.VehicleFilter, dryRun bool) (ids []int, err error) {
+	if dryRun {
+		return c.sv.BulkDelete(ctx, f, dryRun)
+	}
+	err = c.mutate(func() (err error) {
+		ids, err = c.sv.BulkDelete(ctx, f, dryRun)
+		return
+	})
+	return
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"Code_Review_N_1/internal"
+	"Code_Review_N_1/internal/normalize"
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCache_SynonymsShareEntry(t *testing.T) {
+	sv, _ := newTestDefault(10)
+	c := NewCache(sv, normalize.NewVehicleSynonyms(normalize.DefaultSynonyms()), time.Minute)
+	ctx := context.Background()
+
+	for _, brand := range []string{"Chevy", "chevrolet", "CHEVROLET"} {
+		if _, err := c.FindByBrandAndYearRange(ctx, brand, 1990, 2010); err != nil && !errors.Is(err, internal.ErrServiceVehicleNotFound) {
+			t.Fatalf("FindByBrandAndYearRange(%q) error = %v", brand, err)
+		}
+	}
+	for _, color := range []string{"grey", "Gray"} {
+		if _, err := c.FindByColorAndYear(ctx, color, 2000); err != nil && !errors.Is(err, internal.ErrServiceVehicleNotFound) {
+			t.Fatalf("FindByColorAndYear(%q) error = %v", color, err)
+		}
+	}
+
+	st := c.Stats()
+	if st.Entries != 2 || st.Misses != 2 || st.Hits != 3 {
+		t.Fatalf("stats = %+v, want 2 entries, 2 misses and 3 hits", st)
+	}
+}
+
+// blockingFindAll is a vehicle service whose FindAll reads the vehicles and then waits on release before returning them.
+type blockingFindAll struct {
+	internal.ServiceVehicle
+	loaded  chan struct{}
+	release chan struct{}
+}
+
+func (s *blockingFindAll) FindAll(ctx context.Context) ([]internal.Vehicle, error) {
+	v, err := s.ServiceVehicle.FindAll(ctx)
+	if s.loaded != nil {
+		close(s.loaded)
+		<-s.release
+		s.loaded = nil
+	}
+	return v, err
+}
+
+func TestCache_QueryRacingWriteIsNotStored(t *testing.T) {
+	sv, _ := newTestDefault(1)
+	bl := &blockingFindAll{ServiceVehicle: sv, loaded: make(chan struct{}), release: make(chan struct{})}
+	c := NewCache(bl, normalize.NewVehicleSynonyms(normalize.DefaultSynonyms()), time.Minute)
+	ctx := context.Background()
+
+	// a query loads the vehicles before the write and finishes after it
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = c.FindAll(ctx)
+	}()
+	<-bl.loaded
+	if err := c.UpdateMaxSpeed(ctx, 1, 200); err != nil {
+		t.Fatalf("UpdateMaxSpeed() error = %v", err)
+	}
+	close(bl.release)
+	<-done
+
+	v, err := c.FindAll(ctx)
+	if err != nil {
+		t.Fatalf("FindAll() error = %v", err)
+	}
+	if v[0].Attributes.MaxSpeed != 200 {
+		t.Fatalf("MaxSpeed = %d after the write, want 200", v[0].Attributes.MaxSpeed)
+	}
+}
+
+func TestCache_ConcurrentReadsNeverStale(t *testing.T) {
+	sv, _ := newTestDefault(50)
+	c := NewCache(sv, normalize.NewVehicleSynonyms(normalize.DefaultSynonyms()), time.Minute)
+	ctx := context.Background()
+
+	// written is the last max speed the writer finished storing
+	var written atomic.Int64
+	written.Store(150)
+	stop := make(chan struct{})
+
+	var wg sync.WaitGroup
+	var stale atomic.Int64
+	for r := 0; r < 8; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				want := written.Load()
+				v, err := c.FindAll(ctx)
+				if err != nil {
+					t.Errorf("FindAll() error = %v", err)
+					return
+				}
+				if int64(v[0].Attributes.MaxSpeed) < want {
+					stale.Add(1)
+				}
+			}
+		}()
+	}
+
+	for speed := 151; speed <= 400; speed++ {
+		if err := c.UpdateMaxSpeed(ctx, 1, speed); err != nil {
+			t.Fatalf("UpdateMaxSpeed() error = %v", err)
+		}
+		written.Store(int64(speed))
+	}
+	close(stop)
+	wg.Wait()
+
+	if n := stale.Load(); n > 0 {
+		t.Fatalf("%d reads returned a max speed older than a finished write", n)
+	}
+}
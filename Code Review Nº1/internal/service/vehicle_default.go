@@ -2,25 +2,38 @@ package service
 
 import (
 	"Code_Review_N_1/internal"
+	"context"
 	"errors"
 	"fmt"
 	"time"
 )
 
 // NewDefault returns a new instance of a vehicle service.
-func NewDefault(rp internal.RepositoryVehicle) *Default {
-	return &Default{rp: rp}
+// - th are the footprints that separate the size classes of the derived metrics, CompactMax below LargeMin
+// - au is the log of the bulk operations
+func NewDefault(rp internal.RepositoryVehicle, bs internal.VehicleEventBus, nz internal.VehicleNormalizer, th internal.VehicleSizeThresholds, au internal.RepositoryVehicleAudit) (*Default, error) {
+	if err := validateSizeThresholds(th); err != nil {
+		return nil, err
+	}
+	return &Default{rp: rp, bs: bs, nz: nz, th: th, au: au}, nil
 }
 
 // Default is an struct that represents a vehicle service.
+// - every mutation is published to the event bus
+// - categorical attributes are compared by their normalized form
+// - the vehicles found carry their derived metrics
 type Default struct {
 	rp internal.RepositoryVehicle
+	bs internal.VehicleEventBus
+	nz internal.VehicleNormalizer
+	th internal.VehicleSizeThresholds
+	au internal.RepositoryVehicleAudit
 }
 
 // FindAll returns all vehicles.
-func (s *Default) FindAll() (v []internal.Vehicle, err error) {
+func (s *Default) FindAll(ctx context.Context) (v []internal.Vehicle, err error) {
 	// get all vehicles from the repository
-	v, err = s.rp.FindAll()
+	v, err = s.rp.FindAll(ctx)
 	if err != nil {
 		if errors.Is(err, internal.ErrRepositoryVehicleNotFound) {
 			err = fmt.Errorf("%w. %v", internal.ErrServiceVehicleNotFound, err)
@@ -28,14 +41,56 @@ func (s *Default) FindAll() (v []internal.Vehicle, err error) {
 		}
 		return
 	}
+	v = s.derive(v)
+	return
+}
+
+// FindByID returns the vehicle with the given id.
+func (s *Default) FindByID(ctx context.Context, id int) (v internal.Vehicle, err error) {
+	v, err = s.rp.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, internal.ErrRepositoryVehicleNotFound) {
+			err = fmt.Errorf("%w. %v", internal.ErrServiceVehicleNotFound, err)
+		}
+		return
+	}
+	v = s.derive([]internal.Vehicle{v})[0]
 	return
 }
 
-func (s *Default) AddVehicle(newVehicle internal.Vehicle) error {
-	return s.rp.AddVehicle(newVehicle)
+// AddVehicle adds a vehicle whose registration is not in use, checked in the same transaction.
+// - a vehicle without id gets the next one of the repository
+func (s *Default) AddVehicle(ctx context.Context, newVehicle internal.Vehicle) (internal.Vehicle, error) {
+	err := s.rp.WithTx(ctx, func(tx internal.RepositoryVehicle) (err error) {
+		newVehicle, err = s.add(ctx, tx, newVehicle)
+		return
+	})
+	if err != nil {
+		return internal.Vehicle{}, err
+	}
+	s.bs.Publish(internal.VehicleEvent{Type: internal.VehicleEventCreated, Vehicle: newVehicle})
+	return s.derive([]internal.Vehicle{newVehicle})[0], nil
+}
+
+// add stores a vehicle in the transaction tx, checking its registration and assigning its id.
+func (s *Default) add(ctx context.Context, tx internal.RepositoryVehicle, vehicle internal.Vehicle) (v internal.Vehicle, err error) {
+	vehicle.Normalized = s.nz.Normalize(vehicle.Attributes)
+	if err = uniqueRegistration(ctx, tx, vehicle.Attributes.Registration); err != nil {
+		return
+	}
+	if vehicle.ID == 0 {
+		if vehicle.ID, err = tx.NextID(ctx); err != nil {
+			return
+		}
+	}
+	if err = tx.AddVehicle(ctx, vehicle); err != nil {
+		return
+	}
+	v = vehicle
+	return
 }
 
-func (s *Default) ValidateVehicleFields(vehicle internal.Vehicle) error {
+func (s *Default) ValidateVehicleFields(ctx context.Context, vehicle internal.Vehicle) error {
 	if vehicle.Attributes.Brand == "" || vehicle.Attributes.Model == "" || vehicle.Attributes.Registration == "" ||
 		vehicle.Attributes.Year == 0 || vehicle.Attributes.Color == "" || vehicle.Attributes.MaxSpeed == 0 ||
 		vehicle.Attributes.FuelType == "" || vehicle.Attributes.Transmission == "" || vehicle.Attributes.Passengers == 0 ||
@@ -45,7 +100,7 @@ func (s *Default) ValidateVehicleFields(vehicle internal.Vehicle) error {
 	return nil
 }
 
-func (s *Default) ValidateDateFormat(date string) error {
+func (s *Default) ValidateDateFormat(ctx context.Context, date string) error {
 	_, err := time.Parse("2006-01-02", date)
 	if err != nil {
 		return fmt.Errorf("invalid date format")
@@ -53,30 +108,42 @@ func (s *Default) ValidateDateFormat(date string) error {
 	return nil
 }
 
-func (s *Default) ValidateUniqueRegistration(registration string) error {
-	// Implement the logic to check uniqueness in your repository
-	// Example: Check if the registration already exists in the repository
-	vehicles, err := s.rp.FindAll()
-	if err != nil {
+// ValidateUniqueRegistration checks that no vehicle has the registration.
+// - AddVehicle and AddMultipleVehicles check it again in their transaction
+func (s *Default) ValidateUniqueRegistration(ctx context.Context, registration string) error {
+	return uniqueRegistration(ctx, s.rp, registration)
+}
+
+// uniqueRegistration checks that no vehicle of rp has the registration.
+func uniqueRegistration(ctx context.Context, rp internal.RepositoryVehicle, registration string) error {
+	vehicles, err := rp.FindAll(ctx)
+	if err != nil && !errors.Is(err, internal.ErrRepositoryVehicleNotFound) {
 		return err
 	}
 	for _, existingVehicle := range vehicles {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		if existingVehicle.Attributes.Registration == registration {
-			return fmt.Errorf("registration must be unique")
+			return internal.ErrServiceVehicleRegistrationExists
 		}
 	}
 	return nil
 }
 
-func (s *Default) FindByColorAndYear(color string, year int) ([]internal.Vehicle, error) {
-	vehicles, err := s.rp.FindAll()
+func (s *Default) FindByColorAndYear(ctx context.Context, color string, year int) ([]internal.Vehicle, error) {
+	color = s.nz.Color(color)
+	vehicles, err := s.rp.FindAll(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	var matchingVehicles []internal.Vehicle
 	for _, vehicle := range vehicles {
-		if vehicle.Attributes.Color == color && vehicle.Attributes.Year == year {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if vehicle.Normalized.Color == color && vehicle.Attributes.Year == year {
 			matchingVehicles = append(matchingVehicles, vehicle)
 		}
 	}
@@ -85,11 +152,12 @@ func (s *Default) FindByColorAndYear(color string, year int) ([]internal.Vehicle
 		return nil, internal.ErrServiceVehicleNotFound
 	}
 
-	return matchingVehicles, nil
+	return s.derive(matchingVehicles), nil
 }
 
-func (s *Default) FindByBrandAndYearRange(brand string, startYear, endYear int) (v []internal.Vehicle, err error) {
-	vehicles, err := s.rp.FindAll()
+func (s *Default) FindByBrandAndYearRange(ctx context.Context, brand string, startYear, endYear int) (v []internal.Vehicle, err error) {
+	brand = s.nz.Brand(brand)
+	vehicles, err := s.rp.FindAll(ctx)
 	if err != nil {
 		if errors.Is(err, internal.ErrRepositoryVehicleNotFound) {
 			err = fmt.Errorf("%w. %v", internal.ErrServiceVehicleNotFound, err)
@@ -98,7 +166,10 @@ func (s *Default) FindByBrandAndYearRange(brand string, startYear, endYear int)
 		return
 	}
 	for _, vehicle := range vehicles {
-		if vehicle.Attributes.Brand == brand && vehicle.Attributes.Year >= startYear && vehicle.Attributes.Year <= endYear {
+		if err = ctx.Err(); err != nil {
+			return nil, err
+		}
+		if vehicle.Normalized.Brand == brand && vehicle.Attributes.Year >= startYear && vehicle.Attributes.Year <= endYear {
 			v = append(v, vehicle)
 		}
 	}
@@ -106,18 +177,22 @@ func (s *Default) FindByBrandAndYearRange(brand string, startYear, endYear int)
 		err = internal.ErrServiceVehicleNotFound
 		return
 	}
-	return v, nil
+	return s.derive(v), nil
 }
 
-func (s *Default) GetAverageSpeedByBrand(brand string) (float64, error) {
-	vehicles, err := s.rp.FindAll()
+func (s *Default) GetAverageSpeedByBrand(ctx context.Context, brand string) (float64, error) {
+	brand = s.nz.Brand(brand)
+	vehicles, err := s.rp.FindAll(ctx)
 	if err != nil {
 		return 0, err
 	}
 
 	var totalSpeed, count int
 	for _, vehicle := range vehicles {
-		if vehicle.Attributes.Brand == brand {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		if vehicle.Normalized.Brand == brand {
 			totalSpeed += vehicle.Attributes.MaxSpeed
 			count++
 		}
@@ -131,28 +206,57 @@ func (s *Default) GetAverageSpeedByBrand(brand string) (float64, error) {
 	return averageSpeed, nil
 }
 
-func (s *Default) AddMultipleVehicles(newVehicles []internal.Vehicle) error {
-	for _, vehicle := range newVehicles {
-		if err := s.rp.AddVehicle(vehicle); err != nil {
-			return err
+// AddMultipleVehicles adds all the vehicles or none of them.
+// - registrations must be unique, among the vehicles and with the ones already stored
+func (s *Default) AddMultipleVehicles(ctx context.Context, newVehicles []internal.Vehicle) ([]internal.Vehicle, error) {
+	vehicles := make([]internal.Vehicle, len(newVehicles))
+	err := s.rp.WithTx(ctx, func(tx internal.RepositoryVehicle) (err error) {
+		for i, vehicle := range newVehicles {
+			if vehicles[i], err = s.add(ctx, tx, vehicle); err != nil {
+				return fmt.Errorf("vehicle %d: %w", i, err)
+			}
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	return nil
+	for _, vehicle := range vehicles {
+		s.bs.Publish(internal.VehicleEvent{Type: internal.VehicleEventCreated, Vehicle: vehicle})
+	}
+	return s.derive(vehicles), nil
 }
 
-func (s *Default) UpdateMaxSpeed(id int, newMaxSpeed int) error {
-	return s.rp.UpdateMaxSpeed(id, newMaxSpeed)
+func (s *Default) UpdateMaxSpeed(ctx context.Context, id int, newMaxSpeed int) error {
+	var vehicle internal.Vehicle
+	err := s.rp.WithTx(ctx, func(tx internal.RepositoryVehicle) (err error) {
+		vehicle, err = tx.FindByID(ctx, id)
+		if err != nil {
+			return
+		}
+		return tx.UpdateMaxSpeed(ctx, id, newMaxSpeed)
+	})
+	if err != nil {
+		return err
+	}
+	vehicle.Attributes.MaxSpeed = newMaxSpeed
+	s.bs.Publish(internal.VehicleEvent{Type: internal.VehicleEventMaxSpeedUpdated, Vehicle: vehicle})
+	return nil
 }
 
-func (s *Default) FindByFuelType(fuelType string) ([]internal.Vehicle, error) {
-	vehicles, err := s.rp.FindAll()
+func (s *Default) FindByFuelType(ctx context.Context, fuelType string) ([]internal.Vehicle, error) {
+	fuelType = s.nz.FuelType(fuelType)
+	vehicles, err := s.rp.FindAll(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	var matchingVehicles []internal.Vehicle
 	for _, vehicle := range vehicles {
-		if vehicle.Attributes.FuelType == fuelType {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if vehicle.Normalized.FuelType == fuelType {
 			matchingVehicles = append(matchingVehicles, vehicle)
 		}
 	}
@@ -161,12 +265,22 @@ func (s *Default) FindByFuelType(fuelType string) ([]internal.Vehicle, error) {
 		return nil, internal.ErrServiceVehicleNotFound
 	}
 
-	return matchingVehicles, nil
+	return s.derive(matchingVehicles), nil
 }
 
-func (s *Default) DeleteVehicleByID(id int) error {
-	if err := s.rp.DeleteByID(id); err != nil {
+func (s *Default) DeleteVehicleByID(ctx context.Context, id int) error {
+	// keep the vehicle for the event
+	var vehicle internal.Vehicle
+	err := s.rp.WithTx(ctx, func(tx internal.RepositoryVehicle) (err error) {
+		vehicle, err = tx.FindByID(ctx, id)
+		if err != nil {
+			return
+		}
+		return tx.DeleteByID(ctx, id)
+	})
+	if err != nil {
 		return err
 	}
+	s.bs.Publish(internal.VehicleEvent{Type: internal.VehicleEventDeleted, Vehicle: vehicle})
 	return nil
 }
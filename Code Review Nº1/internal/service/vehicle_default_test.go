@@ -0,0 +1,184 @@
+package service
+
+import (
+	"Code_Review_N_1/internal"
+	"Code_Review_N_1/internal/bus"
+	"Code_Review_N_1/internal/normalize"
+	"Code_Review_N_1/internal/repository"
+	"Code_Review_N_1/internal/search"
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// newTestDefault returns a vehicle service over n red 2000 vehicles with ids from 1, all registered on 2000-01-01.
+func newTestDefault(n int) (*Default, *repository.VehicleSlice) {
+	v := make([]internal.Vehicle, n)
+	nz := normalize.NewVehicleSynonyms(normalize.DefaultSynonyms())
+	for i := range v {
+		v[i] = internal.Vehicle{ID: i + 1, Attributes: internal.VehicleAttributes{
+			Brand: "Ford", Model: "Fiesta", Registration: "2000-01-01", Year: 2000, Color: "Red", MaxSpeed: 150,
+			FuelType: "gas", Transmission: "manual", Passengers: 4, Height: 150, Width: 170, Weight: 1000,
+		}}
+		v[i].Normalized = nz.Normalize(v[i].Attributes)
+	}
+	rp := repository.NewVehicleSlice(v, n)
+	sv, err := NewDefault(rp, bus.NewVehicleMemory(0, 1), nz, DefaultSizeThresholds(), repository.NewVehicleAuditSlice())
+	if err != nil {
+		panic(err)
+	}
+	return sv, rp
+}
+
+// cancelledAfter is a context whose Err reports context.Canceled after n calls, to cancel in the middle of a scan.
+type cancelledAfter struct {
+	context.Context
+	n int
+}
+
+func (c *cancelledAfter) Err() error {
+	if c.n <= 0 {
+		return context.Canceled
+	}
+	c.n--
+	return nil
+}
+
+func TestDefault_CancelledContext(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name string
+		ctx  func() context.Context
+		call func(ctx context.Context, sv *Default) error
+	}{
+		{"FindAll", func() context.Context { return cancelled }, func(ctx context.Context, sv *Default) error {
+			_, err := sv.FindAll(ctx)
+			return err
+		}},
+		{"FindByColorAndYear", func() context.Context { return cancelled }, func(ctx context.Context, sv *Default) error {
+			_, err := sv.FindByColorAndYear(ctx, "red", 2000)
+			return err
+		}},
+		{"FindByColorAndYear mid scan", func() context.Context { return &cancelledAfter{Context: context.Background(), n: 10} }, func(ctx context.Context, sv *Default) error {
+			_, err := sv.FindByColorAndYear(ctx, "red", 2000)
+			return err
+		}},
+		{"uniqueRegistration", func() context.Context { return cancelled }, func(ctx context.Context, sv *Default) error {
+			return uniqueRegistration(ctx, sv.rp, "2099-01-01")
+		}},
+		{"uniqueRegistration mid scan", func() context.Context { return &cancelledAfter{Context: context.Background(), n: 10} }, func(ctx context.Context, sv *Default) error {
+			return uniqueRegistration(ctx, sv.rp, "2099-01-01")
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sv, _ := newTestDefault(1000)
+
+			err := tt.call(tt.ctx(), sv)
+
+			if !errors.Is(err, context.Canceled) {
+				t.Fatalf("err = %v, want %v", err, context.Canceled)
+			}
+		})
+	}
+}
+
+func TestDefault_AddSameRegistrationConcurrently(t *testing.T) {
+	_, rp := newTestDefault(10)
+	nz := normalize.NewVehicleSynonyms(normalize.DefaultSynonyms())
+	ix := search.NewVehicleInverted(nz)
+	indexed, err := repository.NewVehicleIndexed(context.Background(), rp, ix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sv, err := NewDefault(indexed, bus.NewVehicleMemory(0, 1), nz, DefaultSizeThresholds(), repository.NewVehicleAuditSlice())
+	if err != nil {
+		t.Fatal(err)
+	}
+	vehicle := internal.Vehicle{Attributes: internal.VehicleAttributes{
+		Brand: "Tesla", Model: "Model 3", Registration: "2099-12-31", Year: 2020, Color: "White", MaxSpeed: 225,
+		FuelType: "electric", Transmission: "automatic", Passengers: 5, Height: 144, Width: 185, Weight: 1611,
+	}}
+
+	const n = 20
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if i%2 == 0 {
+				_, errs[i] = sv.AddVehicle(context.Background(), vehicle)
+				return
+			}
+			_, errs[i] = sv.AddMultipleVehicles(context.Background(), []internal.Vehicle{vehicle})
+		}(i)
+	}
+	wg.Wait()
+
+	added := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			added++
+		case !errors.Is(err, internal.ErrServiceVehicleRegistrationExists):
+			t.Errorf("err = %v, want %v", err, internal.ErrServiceVehicleRegistrationExists)
+		}
+	}
+	if added != 1 {
+		t.Fatalf("added = %d, want 1", added)
+	}
+	if m := ix.Search("tesla", 0); len(m) != 1 || m[0].Vehicle.ID != 11 {
+		t.Errorf("indexed = %+v, want only vehicle 11", m)
+	}
+}
+
+func TestDefault_FiltersUseCanonicalValues(t *testing.T) {
+	sv, rp := newTestDefault(0)
+	// stored with their raw spelling, as loaded from the dataset
+	for i, a := range []internal.VehicleAttributes{
+		{Brand: "Chevy", Registration: "2001-01-01", Year: 2001, Color: "grey", FuelType: "petrol", Transmission: "auto"},
+		{Brand: "chevrolet", Registration: "2001-01-02", Year: 2005, Color: "Gray", FuelType: "Gasoline", Transmission: "Automatic"},
+		{Brand: "Ford", Registration: "2001-01-03", Year: 2001, Color: "Red", FuelType: "diesel", Transmission: "manual"},
+	} {
+		if err := rp.AddVehicle(context.Background(), internal.Vehicle{ID: i + 1, Attributes: a, Normalized: sv.nz.Normalize(a)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		find func() ([]internal.Vehicle, error)
+		want []int
+	}{
+		{"FindByBrandAndYearRange", func() ([]internal.Vehicle, error) {
+			return sv.FindByBrandAndYearRange(context.Background(), "CHEVY", 2000, 2010)
+		}, []int{1, 2}},
+		{"FindByColorAndYear", func() ([]internal.Vehicle, error) {
+			return sv.FindByColorAndYear(context.Background(), "gray", 2001)
+		}, []int{1}},
+		{"FindByFuelType", func() ([]internal.Vehicle, error) {
+			return sv.FindByFuelType(context.Background(), "gas")
+		}, []int{1, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vehicles, err := tt.find()
+			if err != nil {
+				t.Fatalf("err = %v", err)
+			}
+
+			got := make([]int, len(vehicles))
+			for i, v := range vehicles {
+				got[i] = v.ID
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("ids = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"Code_Review_N_1/internal"
+	"context"
+	"fmt"
+	"sort"
+)
+
+// DefaultSizeThresholds returns the footprints that split the vehicles dataset in thirds.
+func DefaultSizeThresholds() internal.VehicleSizeThresholds {
+	return internal.VehicleSizeThresholds{CompactMax: 10000, LargeMin: 28000}
+}
+
+// validateSizeThresholds checks that the size thresholds are not negative and that compact ends before large starts.
+func validateSizeThresholds(th internal.VehicleSizeThresholds) error {
+	switch {
+	case th.CompactMax < 0, th.LargeMin < 0:
+		return fmt.Errorf("%w: footprints can't be negative", internal.ErrServiceVehicleSizeThresholdsInvalid)
+	case th.CompactMax >= th.LargeMin:
+		return fmt.Errorf("%w: compact max %v must be lower than large min %v", internal.ErrServiceVehicleSizeThresholdsInvalid, th.CompactMax, th.LargeMin)
+	}
+	return nil
+}
+
+// metrics returns the metrics derived from the attributes of a vehicle.
+func (s *Default) metrics(v internal.Vehicle) (m internal.VehicleMetrics) {
+	m.Footprint = v.Attributes.Height * v.Attributes.Width
+	if v.Attributes.Passengers > 0 {
+		m.WeightPerPassenger = v.Attributes.Weight / float64(v.Attributes.Passengers)
+	}
+	switch {
+	case m.Footprint < s.th.CompactMax:
+		m.SizeClass = internal.VehicleSizeCompact
+	case m.Footprint >= s.th.LargeMin:
+		m.SizeClass = internal.VehicleSizeLarge
+	default:
+		m.SizeClass = internal.VehicleSizeMid
+	}
+	return
+}
+
+// derive sets the derived metrics of the vehicles.
+func (s *Default) derive(vehicles []internal.Vehicle) []internal.Vehicle {
+	for i := range vehicles {
+		vehicles[i].Metrics = s.metrics(vehicles[i])
+	}
+	return vehicles
+}
+
+// FilterByMetrics returns the vehicles matching the query over their derived metrics, in the order of the query.
+func (s *Default) FilterByMetrics(ctx context.Context, vehicles []internal.Vehicle, q internal.VehicleMetricsQuery) (v []internal.Vehicle, err error) {
+	// validate query
+	switch q.SizeClass {
+	case "", internal.VehicleSizeCompact, internal.VehicleSizeMid, internal.VehicleSizeLarge:
+	default:
+		err = fmt.Errorf("%w: unknown size class %q", internal.ErrServiceVehicleMetricsQueryInvalid, q.SizeClass)
+		return
+	}
+	var key func(m internal.VehicleMetrics) float64
+	switch q.SortBy {
+	case "":
+	case internal.VehicleSortFootprint:
+		key = func(m internal.VehicleMetrics) float64 { return m.Footprint }
+	case internal.VehicleSortWeightPerPassenger:
+		key = func(m internal.VehicleMetrics) float64 { return m.WeightPerPassenger }
+	default:
+		err = fmt.Errorf("%w: unknown sort %q", internal.ErrServiceVehicleMetricsQueryInvalid, q.SortBy)
+		return
+	}
+
+	// filter
+	v = make([]internal.Vehicle, 0, len(vehicles))
+	for _, vehicle := range vehicles {
+		if err = ctx.Err(); err != nil {
+			return
+		}
+		m := s.metrics(vehicle)
+		if (q.SizeClass != "" && m.SizeClass != q.SizeClass) ||
+			(q.FootprintMin != 0 && m.Footprint < q.FootprintMin) ||
+			(q.FootprintMax != 0 && m.Footprint > q.FootprintMax) ||
+			(q.WeightPerPassengerMin != 0 && m.WeightPerPassenger < q.WeightPerPassengerMin) ||
+			(q.WeightPerPassengerMax != 0 && m.WeightPerPassenger > q.WeightPerPassengerMax) {
+			continue
+		}
+		vehicle.Metrics = m
+		v = append(v, vehicle)
+	}
+
+	// sort
+	// - ties keep their order
+	if key != nil {
+		sort.SliceStable(v, func(i, j int) bool {
+			if q.Descending {
+				return key(v[i].Metrics) > key(v[j].Metrics)
+			}
+			return key(v[i].Metrics) < key(v[j].Metrics)
+		})
+	}
+	return
+}
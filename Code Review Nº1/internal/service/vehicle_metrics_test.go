@@ -0,0 +1,130 @@
+package service
+
+import (
+	"Code_Review_N_1/internal"
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+// newMetricsDefault returns a vehicle service whose compact vehicles are under 100 and whose large ones are from 200.
+func newMetricsDefault(t *testing.T) *Default {
+	t.Helper()
+	sv, err := NewDefault(nil, nil, nil, internal.VehicleSizeThresholds{CompactMax: 100, LargeMin: 200}, nil)
+	if err != nil {
+		t.Fatalf("NewDefault() error = %v", err)
+	}
+	return sv
+}
+
+// sized returns a vehicle of id with a footprint of height by width, and weight for its passengers.
+func sized(id int, height, width, weight float64, passengers int) internal.Vehicle {
+	return internal.Vehicle{ID: id, Attributes: internal.VehicleAttributes{Height: height, Width: width, Weight: weight, Passengers: passengers}}
+}
+
+func TestNewDefault_SizeThresholds(t *testing.T) {
+	tests := []struct {
+		name    string
+		th      internal.VehicleSizeThresholds
+		wantErr bool
+	}{
+		{name: "default", th: DefaultSizeThresholds()},
+		{name: "compact from zero", th: internal.VehicleSizeThresholds{CompactMax: 0, LargeMin: 1}},
+		{name: "negative compact max", th: internal.VehicleSizeThresholds{CompactMax: -1, LargeMin: 100}, wantErr: true},
+		{name: "negative large min", th: internal.VehicleSizeThresholds{CompactMax: -10, LargeMin: -1}, wantErr: true},
+		{name: "no mid class", th: internal.VehicleSizeThresholds{CompactMax: 100, LargeMin: 100}, wantErr: true},
+		{name: "out of order", th: internal.VehicleSizeThresholds{CompactMax: 200, LargeMin: 100}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewDefault(nil, nil, nil, tt.th, nil)
+
+			if tt.wantErr != errors.Is(err, internal.ErrServiceVehicleSizeThresholdsInvalid) {
+				t.Fatalf("NewDefault() error = %v, want error %t", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDefault_Metrics(t *testing.T) {
+	sv := newMetricsDefault(t)
+
+	tests := []struct {
+		name    string
+		vehicle internal.Vehicle
+		want    internal.VehicleMetrics
+	}{
+		{name: "footprint and weight per passenger", vehicle: sized(1, 5, 10, 1000, 4),
+			want: internal.VehicleMetrics{Footprint: 50, WeightPerPassenger: 250, SizeClass: internal.VehicleSizeCompact}},
+		{name: "without passengers", vehicle: sized(1, 5, 10, 1000, 0),
+			want: internal.VehicleMetrics{Footprint: 50, WeightPerPassenger: 0, SizeClass: internal.VehicleSizeCompact}},
+		{name: "just under compact max", vehicle: sized(1, 1, 99.5, 1, 1),
+			want: internal.VehicleMetrics{Footprint: 99.5, WeightPerPassenger: 1, SizeClass: internal.VehicleSizeCompact}},
+		{name: "at compact max", vehicle: sized(1, 10, 10, 1, 1),
+			want: internal.VehicleMetrics{Footprint: 100, WeightPerPassenger: 1, SizeClass: internal.VehicleSizeMid}},
+		{name: "just under large min", vehicle: sized(1, 1, 199.5, 1, 1),
+			want: internal.VehicleMetrics{Footprint: 199.5, WeightPerPassenger: 1, SizeClass: internal.VehicleSizeMid}},
+		{name: "at large min", vehicle: sized(1, 10, 20, 1, 1),
+			want: internal.VehicleMetrics{Footprint: 200, WeightPerPassenger: 1, SizeClass: internal.VehicleSizeLarge}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := sv.metrics(tt.vehicle)
+
+			if got != tt.want {
+				t.Fatalf("metrics() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefault_FilterByMetrics(t *testing.T) {
+	sv := newMetricsDefault(t)
+	// footprints 50, 150, 250, 150 and weights per passenger 100, 50, 200, 0
+	vehicles := []internal.Vehicle{
+		sized(1, 5, 10, 400, 4),
+		sized(2, 10, 15, 100, 2),
+		sized(3, 10, 25, 200, 1),
+		sized(4, 10, 15, 100, 0),
+	}
+
+	tests := []struct {
+		name string
+		q    internal.VehicleMetricsQuery
+		want []int
+	}{
+		{name: "no filter", want: []int{1, 2, 3, 4}},
+		{name: "size class", q: internal.VehicleMetricsQuery{SizeClass: internal.VehicleSizeMid}, want: []int{2, 4}},
+		{name: "footprint bounds are inclusive", q: internal.VehicleMetricsQuery{FootprintMin: 150, FootprintMax: 250}, want: []int{2, 3, 4}},
+		{name: "weight per passenger bounds", q: internal.VehicleMetricsQuery{WeightPerPassengerMin: 50, WeightPerPassengerMax: 100}, want: []int{1, 2}},
+		{name: "combined", q: internal.VehicleMetricsQuery{SizeClass: internal.VehicleSizeMid, WeightPerPassengerMin: 1}, want: []int{2}},
+		{name: "sort keeps ties in order", q: internal.VehicleMetricsQuery{SortBy: internal.VehicleSortFootprint}, want: []int{1, 2, 4, 3}},
+		{name: "sort descending", q: internal.VehicleMetricsQuery{SortBy: internal.VehicleSortWeightPerPassenger, Descending: true}, want: []int{3, 1, 2, 4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := sv.FilterByMetrics(context.Background(), slices.Clone(vehicles), tt.q)
+			if err != nil {
+				t.Fatalf("FilterByMetrics() error = %v", err)
+			}
+
+			got := make([]int, len(v))
+			for i := range v {
+				got[i] = v[i].ID
+				if v[i].Metrics != sv.metrics(v[i]) {
+					t.Fatalf("vehicle %d metrics = %+v, want them derived", v[i].ID, v[i].Metrics)
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("ids = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	for _, q := range []internal.VehicleMetricsQuery{{SizeClass: "huge"}, {SortBy: "speed"}} {
+		if _, err := sv.FilterByMetrics(context.Background(), vehicles, q); !errors.Is(err, internal.ErrServiceVehicleMetricsQueryInvalid) {
+			t.Fatalf("FilterByMetrics(%+v) error = %v, want %v", q, err, internal.ErrServiceVehicleMetricsQueryInvalid)
+		}
+	}
+}
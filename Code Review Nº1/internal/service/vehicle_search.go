@@ -0,0 +1,25 @@
+package service
+
+import (
+	"Code_Review_N_1/internal"
+	"context"
+)
+
+// NewSearch returns a new instance of a vehicle search service.
+func NewSearch(ix internal.VehicleIndex) *Search {
+	return &Search{ix: ix}
+}
+
+// Search is an struct that represents a vehicle search service over a full-text index.
+type Search struct {
+	ix internal.VehicleIndex
+}
+
+// Search returns the vehicles whose brand, model, color or registration match the query, best first.
+func (s *Search) Search(ctx context.Context, query string, limit int) (m []internal.VehicleMatch, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	m = s.ix.Search(query, limit)
+	return
+}
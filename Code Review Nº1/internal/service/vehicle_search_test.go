@@ -0,0 +1,35 @@
+package service
+
+import (
+	"Code_Review_N_1/internal"
+	"Code_Review_N_1/internal/normalize"
+	"Code_Review_N_1/internal/search"
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestSearch_CanonicalValues(t *testing.T) {
+	ix := search.NewVehicleInverted(normalize.NewVehicleSynonyms(normalize.DefaultSynonyms()))
+	ix.Index(internal.Vehicle{ID: 1, Attributes: internal.VehicleAttributes{Brand: "Chevrolet", Model: "Camaro", Color: "Gray"}})
+	ix.Index(internal.Vehicle{ID: 2, Attributes: internal.VehicleAttributes{Brand: "chevy", Model: "Malibu", Color: "grey"}})
+	ix.Index(internal.Vehicle{ID: 3, Attributes: internal.VehicleAttributes{Brand: "Ford", Model: "Ka", Color: "Red"}})
+	sv := NewSearch(ix)
+
+	for _, query := range []string{"chevy", "Chevrolet", "grey", "GRAY"} {
+		t.Run(query, func(t *testing.T) {
+			m, err := sv.Search(context.Background(), query, 0)
+			if err != nil {
+				t.Fatalf("err = %v", err)
+			}
+
+			got := make([]int, len(m))
+			for i := range m {
+				got[i] = m[i].Vehicle.ID
+			}
+			if want := []int{1, 2}; !slices.Equal(got, want) {
+				t.Fatalf("ids = %v, want %v", got, want)
+			}
+		})
+	}
+}
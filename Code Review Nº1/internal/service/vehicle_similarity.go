@@ -0,0 +1,233 @@
+package service
+
+import (
+	"Code_Review_N_1/internal"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalidSimilarityWeights is returned when similarity weights can not be parsed.
+	ErrInvalidSimilarityWeights = errors.New("service: invalid similarity weights")
+)
+
+// DefaultSimilarityWeights returns the weights that value every attribute the same.
+func DefaultSimilarityWeights() internal.VehicleSimilarityWeights {
+	return internal.VehicleSimilarityWeights{
+		MaxSpeed:     1,
+		Weight:       1,
+		Height:       1,
+		Width:        1,
+		Passengers:   1,
+		Year:         1,
+		FuelType:     1,
+		Transmission: 1,
+		Brand:        1,
+	}
+}
+
+// ParseSimilarityWeights overrides the weights w in the form "<attribute>=<weight>,..." (e.g. "brand=2,year=0.5").
+// - attributes use their json names
+func ParseSimilarityWeights(s string, w internal.VehicleSimilarityWeights) (internal.VehicleSimilarityWeights, error) {
+	fields := similarityFields(&w)
+	for _, entry := range strings.Split(s, ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(entry, "=")
+		field, known := fields[strings.TrimSpace(name)]
+		if !ok || !known {
+			return w, fmt.Errorf("%w: %q", ErrInvalidSimilarityWeights, entry)
+		}
+		weight, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || weight < 0 {
+			return w, fmt.Errorf("%w: %q", ErrInvalidSimilarityWeights, entry)
+		}
+		*field = weight
+	}
+	return w, nil
+}
+
+// similarityFields returns the weights of w by json name.
+func similarityFields(w *internal.VehicleSimilarityWeights) map[string]*float64 {
+	return map[string]*float64{
+		"max_speed":    &w.MaxSpeed,
+		"weight":       &w.Weight,
+		"height":       &w.Height,
+		"width":        &w.Width,
+		"passengers":   &w.Passengers,
+		"year":         &w.Year,
+		"fuel_type":    &w.FuelType,
+		"transmission": &w.Transmission,
+		"brand":        &w.Brand,
+	}
+}
+
+// NewSimilarity returns a new instance of a vehicle similarity service.
+func NewSimilarity(rp internal.RepositoryVehicle, w internal.VehicleSimilarityWeights) *Similarity {
+	return &Similarity{rp: rp, w: w}
+}
+
+// Similarity is an struct that represents a vehicle similarity service.
+// - the distance is a weighted euclidean distance over the numeric attributes scaled with the fleet,
+// plus the weight of every categorical attribute that does not match
+type Similarity struct {
+	rp internal.RepositoryVehicle
+	w  internal.VehicleSimilarityWeights
+}
+
+// numeric returns the numeric attributes of a vehicle, in the order of the weights.
+func (s *Similarity) numeric(v internal.Vehicle) [6]float64 {
+	return [6]float64{
+		float64(v.Attributes.MaxSpeed),
+		v.Attributes.Weight,
+		v.Attributes.Height,
+		v.Attributes.Width,
+		float64(v.Attributes.Passengers),
+		float64(v.Attributes.Year),
+	}
+}
+
+// FindSimilar returns the k vehicles most similar to the vehicle with the given id, most similar first.
+func (s *Similarity) FindSimilar(ctx context.Context, id int, k int) (sim []internal.VehicleSimilar, err error) {
+	target, err := s.rp.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, internal.ErrRepositoryVehicleNotFound) {
+			err = fmt.Errorf("%w. %v", internal.ErrServiceVehicleNotFound, err)
+		}
+		return
+	}
+	vehicles, err := s.rp.FindAll(ctx)
+	if err != nil {
+		return
+	}
+
+	// feature scaling
+	// - range of every numeric attribute in the fleet
+	var lo, hi [6]float64
+	for i, v := range vehicles {
+		f := s.numeric(v)
+		for j := range f {
+			if i == 0 || f[j] < lo[j] {
+				lo[j] = f[j]
+			}
+			if i == 0 || f[j] > hi[j] {
+				hi[j] = f[j]
+			}
+		}
+	}
+	weights := [6]float64{s.w.MaxSpeed, s.w.Weight, s.w.Height, s.w.Width, s.w.Passengers, s.w.Year}
+
+	// distances
+	t := s.numeric(target)
+	sim = make([]internal.VehicleSimilar, 0, len(vehicles))
+	for _, v := range vehicles {
+		if err = ctx.Err(); err != nil {
+			return
+		}
+		if v.ID == target.ID {
+			continue
+		}
+		var d float64
+		f := s.numeric(v)
+		for j := range f {
+			if hi[j] > lo[j] {
+				diff := (f[j] - t[j]) / (hi[j] - lo[j])
+				d += weights[j] * diff * diff
+			}
+		}
+		if v.Normalized.FuelType != target.Normalized.FuelType {
+			d += s.w.FuelType
+		}
+		if v.Normalized.Transmission != target.Normalized.Transmission {
+			d += s.w.Transmission
+		}
+		if v.Normalized.Brand != target.Normalized.Brand {
+			d += s.w.Brand
+		}
+		sim = append(sim, internal.VehicleSimilar{Vehicle: v, Distance: math.Sqrt(d)})
+	}
+
+	// rank
+	sort.Slice(sim, func(i, j int) bool {
+		if sim[i].Distance != sim[j].Distance {
+			return sim[i].Distance < sim[j].Distance
+		}
+		return sim[i].Vehicle.ID < sim[j].Vehicle.ID
+	})
+	if len(sim) > k {
+		sim = sim[:k]
+	}
+	return
+}
+
+// Compare returns a side-by-side comparison of the vehicles with the given ids.
+// - at least two different vehicles are required
+func (s *Similarity) Compare(ctx context.Context, ids []int) (c internal.VehicleComparison, err error) {
+	if len(ids) < 2 {
+		err = fmt.Errorf("%w: at least two vehicles are required", internal.ErrServiceVehicleCompareInvalid)
+		return
+	}
+	seen := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			err = fmt.Errorf("%w: vehicle %d is repeated", internal.ErrServiceVehicleCompareInvalid, id)
+			return
+		}
+		seen[id] = true
+	}
+
+	// vehicles
+	c.Vehicles = make([]internal.Vehicle, len(ids))
+	for i, id := range ids {
+		c.Vehicles[i], err = s.rp.FindByID(ctx, id)
+		if err != nil {
+			if errors.Is(err, internal.ErrRepositoryVehicleNotFound) {
+				err = fmt.Errorf("%w: id %d", internal.ErrServiceVehicleNotFound, id)
+			}
+			return
+		}
+	}
+
+	// fields
+	// - value is displayed, key is compared
+	fields := []struct {
+		name  string
+		value func(v internal.Vehicle) any
+		key   func(v internal.Vehicle) any
+	}{
+		{"brand", func(v internal.Vehicle) any { return v.Attributes.Brand }, func(v internal.Vehicle) any { return v.Normalized.Brand }},
+		{"model", func(v internal.Vehicle) any { return v.Attributes.Model }, nil},
+		{"registration", func(v internal.Vehicle) any { return v.Attributes.Registration }, nil},
+		{"year", func(v internal.Vehicle) any { return v.Attributes.Year }, nil},
+		{"color", func(v internal.Vehicle) any { return v.Attributes.Color }, func(v internal.Vehicle) any { return v.Normalized.Color }},
+		{"max_speed", func(v internal.Vehicle) any { return v.Attributes.MaxSpeed }, nil},
+		{"fuel_type", func(v internal.Vehicle) any { return v.Attributes.FuelType }, func(v internal.Vehicle) any { return v.Normalized.FuelType }},
+		{"transmission", func(v internal.Vehicle) any { return v.Attributes.Transmission }, func(v internal.Vehicle) any { return v.Normalized.Transmission }},
+		{"passengers", func(v internal.Vehicle) any { return v.Attributes.Passengers }, nil},
+		{"height", func(v internal.Vehicle) any { return v.Attributes.Height }, nil},
+		{"width", func(v internal.Vehicle) any { return v.Attributes.Width }, nil},
+		{"weight", func(v internal.Vehicle) any { return v.Attributes.Weight }, nil},
+	}
+	c.Fields = make([]internal.VehicleFieldComparison, len(fields))
+	for i, f := range fields {
+		key := f.key
+		if key == nil {
+			key = f.value
+		}
+		fc := internal.VehicleFieldComparison{Field: f.name, Values: make([]any, len(c.Vehicles)), Equal: true}
+		for j, v := range c.Vehicles {
+			fc.Values[j] = f.value(v)
+			if key(v) != key(c.Vehicles[0]) {
+				fc.Equal = false
+			}
+		}
+		c.Fields[i] = fc
+	}
+	return
+}
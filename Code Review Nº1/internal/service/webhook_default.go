@@ -0,0 +1,411 @@
+package service
+
+import (
+	"Code_Review_N_1/internal"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	// HeaderWebhookSignature is the header with the signature of a webhook payload: t=<unix time>,v1=<hex hmac-sha256 of "<t>.<body>">.
+	HeaderWebhookSignature = "X-Webhook-Signature"
+	// HeaderWebhookEvent is the header with the type of the delivered event.
+	HeaderWebhookEvent = "X-Webhook-Event"
+	// HeaderWebhookDelivery is the header with the identifier of the delivery, shared by its retries.
+	HeaderWebhookDelivery = "X-Webhook-Delivery"
+)
+
+// ConfigWebhookDefault is an struct that contains the configuration of the webhook deliveries.
+type ConfigWebhookDefault struct {
+	// Workers is the number of concurrent deliveries.
+	Workers int
+	// MaxAttempts is the number of attempts before a delivery is dead lettered.
+	MaxAttempts int
+	// Backoff is the wait before the first retry, doubled on every retry.
+	Backoff time.Duration
+	// MaxBackoff is the maximum wait between retries.
+	MaxBackoff time.Duration
+	// Timeout is the time a receiver has to answer.
+	Timeout time.Duration
+	// AllowPrivateHosts allows webhooks on loopback, link-local and private addresses, such as receivers of the same network.
+	AllowPrivateHosts bool
+}
+
+// NewWebhookDefault returns a new instance of a webhook service.
+func NewWebhookDefault(rp internal.RepositoryWebhook, bs internal.VehicleEventBus, cfg ConfigWebhookDefault) *WebhookDefault {
+	// - the addresses are checked again when connecting, a host may resolve to another address after its creation
+	dialer := &net.Dialer{Timeout: cfg.Timeout}
+	if !cfg.AllowPrivateHosts {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || privateIP(ip) {
+				return fmt.Errorf("%w: %s is not a public address", internal.ErrServiceWebhookInvalid, host)
+			}
+			return nil
+		}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &WebhookDefault{
+		rp:     rp,
+		bs:     bs,
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.Timeout, Transport: transport},
+		queue:  make(chan webhookDelivery, cfg.Workers),
+		done:   make(chan struct{}),
+	}
+}
+
+// privateIP reports whether ip is a loopback, link-local, private or unspecified address.
+func privateIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsPrivate() || ip.IsUnspecified()
+}
+
+// validateHost checks that host only resolves to public addresses, unless private hosts are allowed.
+func (s *WebhookDefault) validateHost(ctx context.Context, host string) error {
+	if s.cfg.AllowPrivateHosts {
+		return nil
+	}
+
+	ips := []net.IP{net.ParseIP(host)}
+	if ips[0] == nil {
+		addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+		if err != nil || len(addrs) == 0 {
+			return fmt.Errorf("%w: host %q can not be resolved", internal.ErrServiceWebhookInvalid, host)
+		}
+		ips = ips[:0]
+		for _, a := range addrs {
+			ips = append(ips, a.IP)
+		}
+	}
+	for _, ip := range ips {
+		if privateIP(ip) {
+			return fmt.Errorf("%w: host %q must not be a loopback, link-local or private address", internal.ErrServiceWebhookInvalid, host)
+		}
+	}
+	return nil
+}
+
+// WebhookDefault is an struct that represents a webhook service.
+// - Run delivers the vehicle events to the webhooks subscribed to them
+// - failed deliveries are retried with exponential backoff and then dead lettered
+type WebhookDefault struct {
+	rp     internal.RepositoryWebhook
+	bs     internal.VehicleEventBus
+	cfg    ConfigWebhookDefault
+	client *http.Client
+	// queue are the deliveries ready to be sent.
+	queue chan webhookDelivery
+	// mu guards lastDeliveryID.
+	mu sync.Mutex
+	// lastDeliveryID is the last id assigned to a delivery.
+	lastDeliveryID int
+	// done is closed when the context of Run is done.
+	done chan struct{}
+}
+
+// webhookDelivery is an struct that represents an event pending delivery to a webhook.
+type webhookDelivery struct {
+	id      int
+	webhook internal.Webhook
+	event   internal.VehicleEvent
+	attempt int
+}
+
+// webhookPayload is an struct that represents the body posted to a webhook.
+type webhookPayload struct {
+	ID      uint64    `json:"id"`
+	Type    string    `json:"type"`
+	Time    time.Time `json:"time"`
+	Vehicle struct {
+		ID           int     `json:"id"`
+		Brand        string  `json:"brand"`
+		Model        string  `json:"model"`
+		Registration string  `json:"registration"`
+		Year         int     `json:"year"`
+		Color        string  `json:"color"`
+		MaxSpeed     int     `json:"max_speed"`
+		FuelType     string  `json:"fuel_type"`
+		Transmission string  `json:"transmission"`
+		Passengers   int     `json:"passengers"`
+		Height       float64 `json:"height"`
+		Width        float64 `json:"width"`
+		Weight       float64 `json:"weight"`
+	} `json:"vehicle"`
+}
+
+// Create validates and stores a new webhook, generating its secret if missing.
+func (s *WebhookDefault) Create(ctx context.Context, w internal.Webhook) (internal.Webhook, error) {
+	// validate
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return internal.Webhook{}, fmt.Errorf("%w: url must be an absolute http(s) url", internal.ErrServiceWebhookInvalid)
+	}
+	if err := s.validateHost(ctx, u.Hostname()); err != nil {
+		return internal.Webhook{}, err
+	}
+	for _, e := range w.Events {
+		switch e {
+		case internal.VehicleEventCreated, internal.VehicleEventMaxSpeedUpdated, internal.VehicleEventUpdated, internal.VehicleEventDeleted:
+		default:
+			return internal.Webhook{}, fmt.Errorf("%w: unknown event %q", internal.ErrServiceWebhookInvalid, e)
+		}
+	}
+
+	// defaults
+	if w.Secret == "" {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			return internal.Webhook{}, err
+		}
+		w.Secret = hex.EncodeToString(b)
+	}
+	w.CreatedAt = time.Now()
+
+	if err := s.rp.Save(ctx, &w); err != nil {
+		return internal.Webhook{}, err
+	}
+	return w, nil
+}
+
+// FindAll returns all webhooks.
+func (s *WebhookDefault) FindAll(ctx context.Context) ([]internal.Webhook, error) {
+	return s.rp.FindAll(ctx)
+}
+
+// FindByID returns a webhook.
+func (s *WebhookDefault) FindByID(ctx context.Context, id int) (internal.Webhook, error) {
+	w, err := s.rp.FindByID(ctx, id)
+	if errors.Is(err, internal.ErrRepositoryWebhookNotFound) {
+		err = fmt.Errorf("%w. %v", internal.ErrServiceWebhookNotFound, err)
+	}
+	return w, err
+}
+
+// DeleteByID removes a webhook, its pending retries are dropped.
+func (s *WebhookDefault) DeleteByID(ctx context.Context, id int) error {
+	err := s.rp.DeleteByID(ctx, id)
+	if errors.Is(err, internal.ErrRepositoryWebhookNotFound) {
+		err = fmt.Errorf("%w. %v", internal.ErrServiceWebhookNotFound, err)
+	}
+	return err
+}
+
+// FindAttempts returns the delivery log of a webhook, newest first.
+func (s *WebhookDefault) FindAttempts(ctx context.Context, webhookID int) ([]internal.WebhookAttempt, error) {
+	a, err := s.rp.FindAttempts(ctx, webhookID)
+	if errors.Is(err, internal.ErrRepositoryWebhookNotFound) {
+		err = fmt.Errorf("%w. %v", internal.ErrServiceWebhookNotFound, err)
+	}
+	return a, err
+}
+
+// FindDeadLetters returns the deliveries that exhausted their retries.
+func (s *WebhookDefault) FindDeadLetters(ctx context.Context) ([]internal.WebhookDeadLetter, error) {
+	return s.rp.FindDeadLetters(ctx)
+}
+
+// RetryDeadLetter schedules a dead letter for delivery again, with a fresh set of attempts.
+// - the dead letters of deleted webhooks are discarded
+func (s *WebhookDefault) RetryDeadLetter(ctx context.Context, id int) error {
+	d, err := s.rp.TakeDeadLetter(ctx, id)
+	if err != nil {
+		if errors.Is(err, internal.ErrRepositoryDeadLetterNotFound) {
+			err = fmt.Errorf("%w. %v", internal.ErrServiceDeadLetterNotFound, err)
+		}
+		return err
+	}
+	w, err := s.FindByID(ctx, d.WebhookID)
+	if err != nil {
+		return err
+	}
+
+	go s.enqueue(webhookDelivery{id: d.DeliveryID, webhook: w, event: d.Event, attempt: 1})
+	return nil
+}
+
+// Run delivers the vehicle events to the webhooks until ctx is done.
+func (s *WebhookDefault) Run(ctx context.Context) {
+	go func() {
+		<-ctx.Done()
+		close(s.done)
+	}()
+
+	// workers
+	var wg sync.WaitGroup
+	for i := 0; i < s.cfg.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case d := <-s.queue:
+					s.deliver(ctx, d)
+				}
+			}
+		}()
+	}
+	defer wg.Wait()
+
+	// fan out the events to the subscribed webhooks
+	var lastID uint64
+	for ctx.Err() == nil {
+		events, cancel := s.bs.Subscribe(lastID, nil)
+		s.dispatch(ctx, events, &lastID)
+		// dropped by the bus when the queue is full, resume from the last dispatched event
+		cancel()
+	}
+}
+
+// dispatch enqueues a delivery for every event and subscribed webhook until events is closed.
+func (s *WebhookDefault) dispatch(ctx context.Context, events <-chan internal.VehicleEvent, lastID *uint64) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e, ok := <-events:
+			if !ok {
+				return
+			}
+			*lastID = e.ID
+			webhooks, err := s.rp.FindAll(ctx)
+			if err != nil {
+				continue
+			}
+			for _, w := range webhooks {
+				if !w.Accepts(e.Type) {
+					continue
+				}
+				s.mu.Lock()
+				s.lastDeliveryID++
+				id := s.lastDeliveryID
+				s.mu.Unlock()
+				s.enqueue(webhookDelivery{id: id, webhook: w, event: e, attempt: 1})
+			}
+		}
+	}
+}
+
+// enqueue waits until a worker takes the delivery or Run stops.
+func (s *WebhookDefault) enqueue(d webhookDelivery) {
+	select {
+	case <-s.done:
+	case s.queue <- d:
+	}
+}
+
+// deliver sends a delivery, scheduling a retry or dead lettering it when it fails.
+func (s *WebhookDefault) deliver(ctx context.Context, d webhookDelivery) {
+	// skip deliveries of deleted webhooks
+	if _, err := s.rp.FindByID(ctx, d.webhook.ID); err != nil {
+		return
+	}
+
+	attempt := internal.WebhookAttempt{
+		DeliveryID: d.id,
+		WebhookID:  d.webhook.ID,
+		EventID:    d.event.ID,
+		EventType:  d.event.Type,
+		Attempt:    d.attempt,
+		Time:       time.Now(),
+	}
+	attempt.StatusCode, attempt.Error = s.send(ctx, d)
+	attempt.Duration = time.Since(attempt.Time)
+	_ = s.rp.AddAttempt(ctx, attempt)
+	if attempt.Error == "" {
+		return
+	}
+
+	// give up
+	if d.attempt >= s.cfg.MaxAttempts {
+		_ = s.rp.AddDeadLetter(ctx, &internal.WebhookDeadLetter{
+			DeliveryID: d.id,
+			WebhookID:  d.webhook.ID,
+			Event:      d.event,
+			Attempts:   d.attempt,
+			LastError:  attempt.Error,
+			Time:       time.Now(),
+		})
+		return
+	}
+
+	// retry with exponential backoff
+	backoff := s.cfg.Backoff << (d.attempt - 1)
+	if backoff > s.cfg.MaxBackoff || backoff <= 0 {
+		backoff = s.cfg.MaxBackoff
+	}
+	d.attempt++
+	time.AfterFunc(backoff, func() { s.enqueue(d) })
+}
+
+// send posts the signed payload of a delivery, returning the status code and the failure, if any.
+func (s *WebhookDefault) send(ctx context.Context, d webhookDelivery) (statusCode int, failure string) {
+	// payload
+	var p webhookPayload
+	p.ID, p.Type, p.Time = d.event.ID, d.event.Type, d.event.Time
+	v := d.event.Vehicle
+	p.Vehicle.ID = v.ID
+	p.Vehicle.Brand, p.Vehicle.Model, p.Vehicle.Registration = v.Attributes.Brand, v.Attributes.Model, v.Attributes.Registration
+	p.Vehicle.Year, p.Vehicle.Color, p.Vehicle.MaxSpeed = v.Attributes.Year, v.Attributes.Color, v.Attributes.MaxSpeed
+	p.Vehicle.FuelType, p.Vehicle.Transmission, p.Vehicle.Passengers = v.Attributes.FuelType, v.Attributes.Transmission, v.Attributes.Passengers
+	p.Vehicle.Height, p.Vehicle.Width, p.Vehicle.Weight = v.Attributes.Height, v.Attributes.Width, v.Attributes.Weight
+	body, err := json.Marshal(p)
+	if err != nil {
+		return 0, err.Error()
+	}
+
+	// request
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err.Error()
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderWebhookEvent, d.event.Type)
+	req.Header.Set(HeaderWebhookDelivery, strconv.Itoa(d.id))
+	req.Header.Set(HeaderWebhookSignature, Sign(d.webhook.Secret, time.Now(), body))
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return 0, err.Error()
+	}
+	defer res.Body.Close()
+	// - drain the body so the connection is reused
+	_, _ = io.Copy(io.Discard, res.Body)
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Sprintf("unexpected status code %d", res.StatusCode)
+	}
+	return res.StatusCode, ""
+}
+
+// Sign returns the value of the signature header of a webhook payload.
+// - receivers recompute the hmac of "<t>.<body>" with their secret and compare it with v1
+func Sign(secret string, t time.Time, body []byte) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "t=" + ts + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
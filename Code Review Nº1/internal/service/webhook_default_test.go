@@ -0,0 +1,197 @@
+package service
+
+import (
+	"Code_Review_N_1/internal"
+	"Code_Review_N_1/internal/bus"
+	"Code_Review_N_1/internal/repository"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestWebhookDefault(allowPrivate bool, maxAttempts int) *WebhookDefault {
+	return NewWebhookDefault(repository.NewWebhookMap(10), bus.NewVehicleMemory(0, 1), ConfigWebhookDefault{
+		Workers:           1,
+		MaxAttempts:       maxAttempts,
+		Backoff:           time.Millisecond,
+		MaxBackoff:        time.Millisecond,
+		Timeout:           time.Second,
+		AllowPrivateHosts: allowPrivate,
+	})
+}
+
+func TestWebhookDefault_Create_RejectsPrivateHosts(t *testing.T) {
+	tests := []struct {
+		url     string
+		allowed bool
+	}{
+		{url: "http://127.0.0.1:8080/hook"},
+		{url: "http://localhost/hook"},
+		{url: "http://[::1]/hook"},
+		{url: "http://169.254.169.254/latest/meta-data"},
+		{url: "http://10.0.0.1/hook"},
+		{url: "http://192.168.1.10/hook"},
+		{url: "http://0.0.0.0/hook"},
+		{url: "https://93.184.216.34/hook", allowed: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			s := newTestWebhookDefault(false, 1)
+
+			_, err := s.Create(context.Background(), internal.Webhook{URL: tt.url})
+
+			if tt.allowed && err != nil {
+				t.Fatalf("Create() error = %v, want nil", err)
+			}
+			if !tt.allowed && !errors.Is(err, internal.ErrServiceWebhookInvalid) {
+				t.Fatalf("Create() error = %v, want %v", err, internal.ErrServiceWebhookInvalid)
+			}
+		})
+	}
+
+	t.Run("allowed by config", func(t *testing.T) {
+		s := newTestWebhookDefault(true, 1)
+
+		if _, err := s.Create(context.Background(), internal.Webhook{URL: "http://127.0.0.1:8080/hook"}); err != nil {
+			t.Fatalf("Create() error = %v, want nil", err)
+		}
+	})
+}
+
+// receivedRequest is a request received by the test receiver.
+type receivedRequest struct {
+	header http.Header
+	body   []byte
+}
+
+func TestWebhookDefault_Deliver(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxAttempts int
+		// statuses are the status codes answered to the attempts, in order
+		statuses   []int
+		deadLetter bool
+	}{
+		{name: "retried after a 5xx", maxAttempts: 3, statuses: []int{http.StatusServiceUnavailable, http.StatusOK}},
+		{name: "dead lettered after the last attempt", maxAttempts: 2, statuses: []int{http.StatusInternalServerError, http.StatusBadGateway}, deadLetter: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// receiver
+			var mu sync.Mutex
+			var received []receivedRequest
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				mu.Lock()
+				received = append(received, receivedRequest{header: r.Header.Clone(), body: body})
+				status := tt.statuses[len(received)-1]
+				mu.Unlock()
+				w.WriteHeader(status)
+				_, _ = w.Write([]byte("ignored"))
+			}))
+			defer srv.Close()
+
+			ctx := context.Background()
+			s := newTestWebhookDefault(true, tt.maxAttempts)
+			wh, err := s.Create(ctx, internal.Webhook{URL: srv.URL, Secret: "s3cret"})
+			if err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+			event := internal.VehicleEvent{ID: 7, Type: internal.VehicleEventCreated, Time: time.Now(), Vehicle: internal.Vehicle{ID: 1}}
+
+			// deliver the first attempt and then the retries scheduled on the queue
+			s.deliver(ctx, webhookDelivery{id: 1, webhook: wh, event: event, attempt: 1})
+			for i := 1; i < len(tt.statuses); i++ {
+				select {
+				case d := <-s.queue:
+					s.deliver(ctx, d)
+				case <-time.After(time.Second):
+					t.Fatalf("retry %d was not scheduled", i)
+				}
+			}
+
+			// requests
+			if len(received) != len(tt.statuses) {
+				t.Fatalf("receiver got %d requests, want %d", len(received), len(tt.statuses))
+			}
+			for i, r := range received {
+				if got := r.header.Get(HeaderWebhookDelivery); got != "1" {
+					t.Errorf("request %d: %s = %q, want %q", i+1, HeaderWebhookDelivery, got, "1")
+				}
+				if got := r.header.Get(HeaderWebhookEvent); got != internal.VehicleEventCreated {
+					t.Errorf("request %d: %s = %q, want %q", i+1, HeaderWebhookEvent, got, internal.VehicleEventCreated)
+				}
+				sig := r.header.Get(HeaderWebhookSignature)
+				ts, ok := strings.CutPrefix(strings.Split(sig, ",")[0], "t=")
+				unix, err := strconv.ParseInt(ts, 10, 64)
+				if !ok || err != nil {
+					t.Fatalf("request %d: malformed signature %q", i+1, sig)
+				}
+				if want := Sign("s3cret", time.Unix(unix, 0), r.body); sig != want {
+					t.Errorf("request %d: signature = %q, want %q", i+1, sig, want)
+				}
+			}
+
+			// delivery log, newest first
+			attempts, err := s.FindAttempts(ctx, wh.ID)
+			if err != nil {
+				t.Fatalf("FindAttempts() error = %v", err)
+			}
+			if len(attempts) != len(tt.statuses) {
+				t.Fatalf("got %d attempts, want %d", len(attempts), len(tt.statuses))
+			}
+			for i, a := range attempts {
+				n := len(tt.statuses) - i
+				status := tt.statuses[n-1]
+				if a.Attempt != n || a.StatusCode != status || a.DeliveryID != 1 || a.EventID != 7 {
+					t.Errorf("attempt %d = %+v, want attempt %d with status %d", i, a, n, status)
+				}
+				if failed := a.Error != ""; failed != (status >= 300) {
+					t.Errorf("attempt %d error = %q with status %d", n, a.Error, status)
+				}
+			}
+
+			// dead letters
+			dl, err := s.FindDeadLetters(ctx)
+			if err != nil {
+				t.Fatalf("FindDeadLetters() error = %v", err)
+			}
+			if tt.deadLetter != (len(dl) == 1) {
+				t.Fatalf("got %d dead letters, want dead lettered %t", len(dl), tt.deadLetter)
+			}
+		})
+	}
+}
+
+func TestWebhookDefault_Deliver_RejectsPrivateAddressWhenConnecting(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	}))
+	defer srv.Close()
+
+	// a host that resolved to a public address at creation and to a private one afterwards
+	ctx := context.Background()
+	s := newTestWebhookDefault(false, 1)
+	wh := internal.Webhook{URL: srv.URL, Secret: "s3cret"}
+	if err := s.rp.Save(ctx, &wh); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	s.deliver(ctx, webhookDelivery{id: 1, webhook: wh, event: internal.VehicleEvent{ID: 1, Type: internal.VehicleEventCreated}, attempt: 1})
+
+	attempts, err := s.FindAttempts(ctx, wh.ID)
+	if err != nil {
+		t.Fatalf("FindAttempts() error = %v", err)
+	}
+	if calls != 0 || len(attempts) != 1 || attempts[0].StatusCode != 0 || !strings.Contains(attempts[0].Error, "not a public address") {
+		t.Fatalf("receiver got %d requests and attempts = %+v, want the connection refused", calls, attempts)
+	}
+}
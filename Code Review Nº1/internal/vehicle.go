@@ -38,4 +38,35 @@ type Vehicle struct {
 	ID 			 int
 	// Attributes is the attributes of the vehicle.
 	Attributes 	 VehicleAttributes
+	// Normalized is the canonical form of the categorical attributes, used to compare them.
+	// - Attributes keeps the original values for display
+	Normalized 	 VehicleCategories `json:"-"`
+	// Metrics are the metrics derived from the attributes, computed by the service.
+	Metrics 	 VehicleMetrics `json:"-"`
+}
+
+// VehicleCategories is an struct that represents the categorical attributes of a vehicle.
+type VehicleCategories struct {
+	// Brand is the brand of the vehicle.
+	Brand 		 string
+	// Color is the color of the vehicle.
+	Color 		 string
+	// FuelType is the fuel type of the vehicle.
+	FuelType 	 string
+	// Transmission is the transmission of the vehicle.
+	Transmission string
+}
+
+// VehicleNormalizer is the interface that wraps the basic methods for normalizing the categorical attributes of a vehicle.
+type VehicleNormalizer interface {
+	// Normalize returns the canonical categorical attributes of a vehicle
+	Normalize(a VehicleAttributes) VehicleCategories
+	// Brand returns the canonical form of a brand
+	Brand(s string) string
+	// Color returns the canonical form of a color
+	Color(s string) string
+	// FuelType returns the canonical form of a fuel type
+	FuelType(s string) string
+	// Transmission returns the canonical form of a transmission
+	Transmission(s string) string
 }
\ No newline at end of file
@@ -0,0 +1,43 @@
+package internal
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	// VehicleAuditBulkUpdate is the action of the entry recorded by a bulk update.
+	VehicleAuditBulkUpdate = "vehicles.bulk_update"
+	// VehicleAuditBulkDelete is the action of the entry recorded by a bulk delete.
+	VehicleAuditBulkDelete = "vehicles.bulk_delete"
+)
+
+// VehicleAuditEntry is an struct that represents a bulk operation applied to the vehicles.
+type VehicleAuditEntry struct {
+	// ID is the sequential identifier of the entry, assigned by the repository.
+	ID int
+	// Time is the time the operation was applied.
+	Time time.Time
+	// Action is the kind of operation.
+	Action string
+	// Filter is the filter of the operation.
+	Filter VehicleFilter
+	// Patch is the patch of the operation, nil for deletes.
+	Patch *VehiclePatch
+	// IDs are the ids of the vehicles affected.
+	IDs []int
+}
+
+// RepositoryVehicleAudit is the interface that wraps the basic methods for a vehicle audit log.
+type RepositoryVehicleAudit interface {
+	// Add stores an entry, assigning its id
+	Add(ctx context.Context, e *VehicleAuditEntry) error
+	// FindAll returns all entries, newest first
+	FindAll(ctx context.Context) ([]VehicleAuditEntry, error)
+}
+
+// ServiceVehicleAudit is the interface that wraps the basic methods for a vehicle audit service.
+type ServiceVehicleAudit interface {
+	// FindAll returns all entries, newest first
+	FindAll(ctx context.Context) ([]VehicleAuditEntry, error)
+}
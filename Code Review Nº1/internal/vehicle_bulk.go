@@ -0,0 +1,31 @@
+package internal
+
+import "errors"
+
+var (
+	// ErrServiceVehicleBulkInvalid is returned when the filter or the patch of a bulk operation is invalid.
+	ErrServiceVehicleBulkInvalid = errors.New("service: invalid bulk operation")
+)
+
+// VehicleFilter is an struct that represents the vehicles affected by a bulk operation.
+// - zero values do not filter, at least one criterion is required
+// - categorical attributes are compared by their normalized form
+type VehicleFilter struct {
+	Brand        string
+	Color        string
+	FuelType     string
+	Transmission string
+	// YearFrom and YearTo bound the fabrication year, inclusive.
+	YearFrom, YearTo int
+	// MaxSpeedMin and MaxSpeedMax bound the maximum speed, inclusive.
+	MaxSpeedMin, MaxSpeedMax int
+}
+
+// VehiclePatch is an struct that represents the changes of a bulk update.
+// - nil fields are kept
+type VehiclePatch struct {
+	MaxSpeed     *int
+	Color        *string
+	FuelType     *string
+	Transmission *string
+}
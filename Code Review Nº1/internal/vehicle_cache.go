@@ -0,0 +1,19 @@
+package internal
+
+// CacheStats is an struct that represents the metrics of a cache.
+type CacheStats struct {
+	// Hits is the number of lookups answered from the cache.
+	Hits uint64
+	// Misses is the number of lookups that reached the underlying service.
+	Misses uint64
+	// Invalidations is the number of times the cache was emptied by a mutation.
+	Invalidations uint64
+	// Entries is the number of entries currently stored.
+	Entries int
+}
+
+// CacheMetrics is the interface that wraps the basic methods for a cache that reports metrics.
+type CacheMetrics interface {
+	// Stats returns the current metrics of the cache
+	Stats() CacheStats
+}
@@ -0,0 +1,44 @@
+package internal
+
+import (
+	"time"
+)
+
+const (
+	// VehicleEventCreated is the type of the event published when a vehicle is added.
+	VehicleEventCreated = "vehicle.created"
+	// VehicleEventMaxSpeedUpdated is the type of the event published when the maximum speed of a vehicle changes.
+	VehicleEventMaxSpeedUpdated = "vehicle.max_speed_updated"
+	// VehicleEventUpdated is the type of the event published when a vehicle is changed by a bulk update.
+	VehicleEventUpdated = "vehicle.updated"
+	// VehicleEventDeleted is the type of the event published when a vehicle is deleted.
+	VehicleEventDeleted = "vehicle.deleted"
+	// VehicleEventReset is the type of the event sent, without vehicle, to a subscriber that resumes after events
+	// no longer kept for replay: it must refetch the vehicles and resume from the id of the reset.
+	VehicleEventReset = "vehicle.reset"
+)
+
+// VehicleEvent is an struct that represents a change of a vehicle.
+type VehicleEvent struct {
+	// ID is the sequential identifier of the event, assigned by the bus.
+	ID uint64
+	// Type is the type of the event.
+	Type string
+	// Time is the time when the event was published.
+	Time time.Time
+	// Vehicle is the vehicle after the change (before it, for deletions, empty for resets).
+	Vehicle Vehicle
+}
+
+// VehicleEventFilter reports whether an event must be delivered to a subscriber.
+type VehicleEventFilter func(e VehicleEvent) bool
+
+// VehicleEventBus is the interface that wraps the basic methods for a vehicle event bus.
+type VehicleEventBus interface {
+	// Publish assigns an id to the event and delivers it to the subscribers
+	Publish(e VehicleEvent) VehicleEvent
+	// Subscribe returns the events matching filter published after lastID, replaying the buffered ones.
+	// - a reset event is sent first when the events after lastID are no longer buffered
+	// - the channel is closed when cancel is called or when the subscriber does not keep up
+	Subscribe(lastID uint64, filter VehicleEventFilter) (events <-chan VehicleEvent, cancel func())
+}
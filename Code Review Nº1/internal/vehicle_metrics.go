@@ -0,0 +1,59 @@
+package internal
+
+import "errors"
+
+const (
+	// VehicleSizeCompact is the size class of the vehicles with a footprint under the compact threshold.
+	VehicleSizeCompact = "compact"
+	// VehicleSizeMid is the size class of the vehicles between the thresholds.
+	VehicleSizeMid = "mid"
+	// VehicleSizeLarge is the size class of the vehicles with a footprint from the large threshold.
+	VehicleSizeLarge = "large"
+)
+
+const (
+	// VehicleSortFootprint sorts the vehicles by footprint.
+	VehicleSortFootprint = "footprint"
+	// VehicleSortWeightPerPassenger sorts the vehicles by weight per passenger.
+	VehicleSortWeightPerPassenger = "weight_per_passenger"
+)
+
+var (
+	// ErrServiceVehicleMetricsQueryInvalid is returned when a query over the derived metrics is invalid.
+	ErrServiceVehicleMetricsQueryInvalid = errors.New("service: invalid vehicle metrics query")
+	// ErrServiceVehicleSizeThresholdsInvalid is returned when the size thresholds are negative or out of order.
+	ErrServiceVehicleSizeThresholdsInvalid = errors.New("service: invalid vehicle size thresholds")
+)
+
+// VehicleMetrics is an struct that represents the metrics derived from the attributes of a vehicle.
+type VehicleMetrics struct {
+	// Footprint is the area of the height by the width of the vehicle.
+	Footprint float64
+	// WeightPerPassenger is the weight of the vehicle divided by its passengers.
+	WeightPerPassenger float64
+	// SizeClass is the size class of the vehicle by footprint (compact, mid or large).
+	SizeClass string
+}
+
+// VehicleSizeThresholds is an struct that represents the footprints that separate the size classes.
+type VehicleSizeThresholds struct {
+	// CompactMax is the footprint from which a vehicle is no longer compact.
+	CompactMax float64
+	// LargeMin is the footprint from which a vehicle is large.
+	LargeMin float64
+}
+
+// VehicleMetricsQuery is an struct that represents a filter and an order over the derived metrics.
+// - zero values do not filter
+type VehicleMetricsQuery struct {
+	// SizeClass is the size class of the vehicles.
+	SizeClass string
+	// FootprintMin and FootprintMax bound the footprint, inclusive.
+	FootprintMin, FootprintMax float64
+	// WeightPerPassengerMin and WeightPerPassengerMax bound the weight per passenger, inclusive.
+	WeightPerPassengerMin, WeightPerPassengerMax float64
+	// SortBy is the metric the vehicles are ordered by, empty keeps the order.
+	SortBy string
+	// Descending orders the vehicles from the highest metric.
+	Descending bool
+}
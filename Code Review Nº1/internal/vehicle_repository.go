@@ -1,6 +1,7 @@
 package internal
 
 import (
+	"context"
 	"errors"
 )
 
@@ -10,11 +11,23 @@ var (
 )
 
 // RepositoryVehicle is the interface that wraps the basic methods for a vehicle repository.
+// - every method receives the request context and must stop as soon as it is done
 type RepositoryVehicle interface {
 	// FindAll returns all vehicles
-	FindAll() (v []Vehicle, err error)
-	AddVehicle(newVehicle Vehicle) error
-	AddMultipleVehicles(newVehicles []Vehicle) error
-	UpdateMaxSpeed(id int, newMaxSpeed int) error
-	DeleteByID(id int) error
+	FindAll(ctx context.Context) (v []Vehicle, err error)
+	// FindByID returns the vehicle with the given id
+	FindByID(ctx context.Context, id int) (v Vehicle, err error)
+	// NextID reserves and returns the id of a new vehicle, never used before
+	NextID(ctx context.Context) (id int, err error)
+	AddVehicle(ctx context.Context, newVehicle Vehicle) error
+	AddMultipleVehicles(ctx context.Context, newVehicles []Vehicle) error
+	UpdateMaxSpeed(ctx context.Context, id int, newMaxSpeed int) error
+	DeleteByID(ctx context.Context, id int) error
+	// UpdateWhere replaces every vehicle matching match with the result of update, all of them or none
+	UpdateWhere(ctx context.Context, match func(Vehicle) bool, update func(Vehicle) Vehicle) (updated []Vehicle, err error)
+	// DeleteWhere removes every vehicle matching match, all of them or none
+	DeleteWhere(ctx context.Context, match func(Vehicle) bool) (deleted []Vehicle, err error)
+	// WithTx runs fn in a transaction: the changes made through tx are committed when fn returns nil
+	// and rolled back otherwise, and no other operation sees or interleaves with them meanwhile
+	WithTx(ctx context.Context, fn func(tx RepositoryVehicle) error) error
 }
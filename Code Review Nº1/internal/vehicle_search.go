@@ -0,0 +1,31 @@
+package internal
+
+import (
+	"context"
+)
+
+// VehicleMatch is an struct that represents a vehicle found by a search.
+type VehicleMatch struct {
+	// Vehicle is the vehicle found.
+	Vehicle Vehicle
+	// Matched is the number of query terms found in the vehicle.
+	Matched int
+	// Score is the relevance of the vehicle for the query, higher is better.
+	Score float64
+}
+
+// VehicleIndex is the interface that wraps the basic methods for a full-text index of vehicles.
+type VehicleIndex interface {
+	// Index adds or replaces a vehicle in the index
+	Index(v Vehicle)
+	// Remove removes a vehicle from the index
+	Remove(id int)
+	// Search returns the vehicles matching the query, best first
+	Search(query string, limit int) []VehicleMatch
+}
+
+// ServiceVehicleSearch is the interface that wraps the basic methods for a vehicle search service.
+type ServiceVehicleSearch interface {
+	// Search returns the vehicles whose brand, model, color or registration match the query, best first
+	Search(ctx context.Context, query string, limit int) ([]VehicleMatch, error)
+}
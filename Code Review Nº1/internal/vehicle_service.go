@@ -1,29 +1,43 @@
 package internal
 
 import (
+	"context"
 	"errors"
 )
 
 var (
 	// ErrServiceVehicleNotFound is returned when no vehicle is found.
 	ErrServiceVehicleNotFound = errors.New("service: vehicle not found")
+	// ErrServiceVehicleRegistrationExists is returned when a vehicle with the same registration already exists.
+	ErrServiceVehicleRegistrationExists = errors.New("service: registration must be unique")
 )
 
 // ServiceVehicle is the interface that wraps the basic methods for a vehicle service.
 // - conections with external apis
 // - business logic
+// - every method receives the request context, which is propagated to the repository
 type ServiceVehicle interface {
 	// FindAll returns all vehicles
-	FindAll() (v []Vehicle, err error)
-	AddVehicle(newVehicle Vehicle) error
-	ValidateVehicleFields(vehicle Vehicle) error
-	ValidateDateFormat(date string) error
-	ValidateUniqueRegistration(registration string) error
-	FindByColorAndYear(color string, year int) ([]Vehicle, error)
-	FindByBrandAndYearRange(brand string, startYear, endYear int) (v []Vehicle, err error)
-	GetAverageSpeedByBrand(brand string) (float64, error)
-	AddMultipleVehicles(newVehicles []Vehicle) error
-	UpdateMaxSpeed(id int, newMaxSpeed int) error
-	FindByFuelType(fuelType string) ([]Vehicle, error)
-	DeleteVehicleByID(id int) error
+	FindAll(ctx context.Context) (v []Vehicle, err error)
+	// FindByID returns the vehicle with the given id
+	FindByID(ctx context.Context, id int) (v Vehicle, err error)
+	// AddVehicle adds a vehicle, assigning it an id when it has none, and returns it as stored
+	AddVehicle(ctx context.Context, newVehicle Vehicle) (v Vehicle, err error)
+	ValidateVehicleFields(ctx context.Context, vehicle Vehicle) error
+	ValidateDateFormat(ctx context.Context, date string) error
+	ValidateUniqueRegistration(ctx context.Context, registration string) error
+	FindByColorAndYear(ctx context.Context, color string, year int) ([]Vehicle, error)
+	FindByBrandAndYearRange(ctx context.Context, brand string, startYear, endYear int) (v []Vehicle, err error)
+	GetAverageSpeedByBrand(ctx context.Context, brand string) (float64, error)
+	// AddMultipleVehicles adds the vehicles like AddVehicle, all of them or none, and returns them as stored
+	AddMultipleVehicles(ctx context.Context, newVehicles []Vehicle) (v []Vehicle, err error)
+	UpdateMaxSpeed(ctx context.Context, id int, newMaxSpeed int) error
+	FindByFuelType(ctx context.Context, fuelType string) ([]Vehicle, error)
+	DeleteVehicleByID(ctx context.Context, id int) error
+	// FilterByMetrics returns the vehicles matching the query over their derived metrics, in the order of the query
+	FilterByMetrics(ctx context.Context, vehicles []Vehicle, q VehicleMetricsQuery) ([]Vehicle, error)
+	// BulkUpdate applies the patch to every vehicle matching the filter and returns their ids, changing nothing with dryRun
+	BulkUpdate(ctx context.Context, f VehicleFilter, p VehiclePatch, dryRun bool) ([]int, error)
+	// BulkDelete removes every vehicle matching the filter and returns their ids, removing nothing with dryRun
+	BulkDelete(ctx context.Context, f VehicleFilter, dryRun bool) ([]int, error)
 }
@@ -0,0 +1,60 @@
+package internal
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// ErrServiceVehicleCompareInvalid is returned when the vehicles to compare are invalid.
+	ErrServiceVehicleCompareInvalid = errors.New("service: invalid vehicles to compare")
+)
+
+// VehicleSimilarityWeights is an struct that represents the weights of the attributes in the distance between vehicles.
+// - numeric attributes are scaled to [0, 1] with the minimum and maximum of the fleet
+// - categorical attributes add their weight when they do not match
+type VehicleSimilarityWeights struct {
+	MaxSpeed     float64
+	Weight       float64
+	Height       float64
+	Width        float64
+	Passengers   float64
+	Year         float64
+	FuelType     float64
+	Transmission float64
+	Brand        float64
+}
+
+// VehicleSimilar is an struct that represents a vehicle similar to another one.
+type VehicleSimilar struct {
+	// Vehicle is the similar vehicle.
+	Vehicle Vehicle
+	// Distance is the weighted distance to the other vehicle, lower is more similar.
+	Distance float64
+}
+
+// VehicleFieldComparison is an struct that represents the values of a field in the compared vehicles.
+type VehicleFieldComparison struct {
+	// Field is the json name of the field.
+	Field string
+	// Values are the values of the field, in the order of the compared vehicles.
+	Values []any
+	// Equal is true when every vehicle has the same value (categorical fields are compared normalized).
+	Equal bool
+}
+
+// VehicleComparison is an struct that represents a side-by-side comparison of vehicles.
+type VehicleComparison struct {
+	// Vehicles are the compared vehicles, in the requested order.
+	Vehicles []Vehicle
+	// Fields are the compared fields.
+	Fields []VehicleFieldComparison
+}
+
+// ServiceVehicleSimilarity is the interface that wraps the basic methods for a vehicle similarity service.
+type ServiceVehicleSimilarity interface {
+	// FindSimilar returns the k vehicles most similar to the vehicle with the given id, most similar first
+	FindSimilar(ctx context.Context, id int, k int) ([]VehicleSimilar, error)
+	// Compare returns a side-by-side comparison of the vehicles with the given ids
+	Compare(ctx context.Context, ids []int) (VehicleComparison, error)
+}
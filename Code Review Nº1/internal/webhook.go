@@ -0,0 +1,72 @@
+package internal
+
+import (
+	"time"
+)
+
+// Webhook is an struct that represents a subscription of an external system to the vehicle events.
+type Webhook struct {
+	// ID is the unique identifier of the webhook.
+	ID int
+	// URL is the endpoint where the events are posted.
+	URL string
+	// Secret is the key used to sign the payloads.
+	Secret string
+	// Events are the event types delivered, all of them when empty.
+	Events []string
+	// CreatedAt is the time when the webhook was created.
+	CreatedAt time.Time
+}
+
+// Accepts reports whether the webhook is subscribed to an event type.
+func (w Webhook) Accepts(eventType string) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, e := range w.Events {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookAttempt is an struct that represents an attempt to deliver an event to a webhook.
+type WebhookAttempt struct {
+	// DeliveryID is the identifier of the delivery, shared by its retries.
+	DeliveryID int
+	// WebhookID is the identifier of the webhook.
+	WebhookID int
+	// EventID is the identifier of the delivered event.
+	EventID uint64
+	// EventType is the type of the delivered event.
+	EventType string
+	// Attempt is the number of the attempt, starting at 1.
+	Attempt int
+	// StatusCode is the status code answered by the receiver, zero when it could not be reached.
+	StatusCode int
+	// Error describes why the attempt failed, empty on success.
+	Error string
+	// Time is the time when the attempt started.
+	Time time.Time
+	// Duration is the time the attempt took.
+	Duration time.Duration
+}
+
+// WebhookDeadLetter is an struct that represents a delivery that exhausted its retries.
+type WebhookDeadLetter struct {
+	// ID is the unique identifier of the dead letter.
+	ID int
+	// DeliveryID is the identifier of the failed delivery.
+	DeliveryID int
+	// WebhookID is the identifier of the webhook.
+	WebhookID int
+	// Event is the event that could not be delivered.
+	Event VehicleEvent
+	// Attempts is the number of attempts made.
+	Attempts int
+	// LastError describes the last failure.
+	LastError string
+	// Time is the time when the delivery was given up.
+	Time time.Time
+}
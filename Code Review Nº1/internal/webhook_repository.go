@@ -0,0 +1,37 @@
+package internal
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// ErrRepositoryWebhookNotFound is returned when a webhook is not found.
+	ErrRepositoryWebhookNotFound = errors.New("repository: webhook not found")
+	// ErrRepositoryDeadLetterNotFound is returned when a dead letter is not found.
+	ErrRepositoryDeadLetterNotFound = errors.New("repository: dead letter not found")
+)
+
+// RepositoryWebhook is the interface that wraps the basic methods for a webhook repository.
+type RepositoryWebhook interface {
+	// FindAll returns all webhooks
+	FindAll(ctx context.Context) (w []Webhook, err error)
+	// FindByID returns the webhook with the given id
+	FindByID(ctx context.Context, id int) (w Webhook, err error)
+	// Save stores a new webhook, assigning its id
+	Save(ctx context.Context, w *Webhook) (err error)
+	// DeleteByID removes a webhook
+	DeleteByID(ctx context.Context, id int) (err error)
+
+	// AddAttempt appends an attempt to the delivery log of its webhook
+	AddAttempt(ctx context.Context, a WebhookAttempt) (err error)
+	// FindAttempts returns the delivery log of a webhook, newest first
+	FindAttempts(ctx context.Context, webhookID int) (a []WebhookAttempt, err error)
+
+	// AddDeadLetter stores a delivery that exhausted its retries, assigning its id
+	AddDeadLetter(ctx context.Context, d *WebhookDeadLetter) (err error)
+	// FindDeadLetters returns all dead letters
+	FindDeadLetters(ctx context.Context) (d []WebhookDeadLetter, err error)
+	// TakeDeadLetter removes and returns a dead letter
+	TakeDeadLetter(ctx context.Context, id int) (d WebhookDeadLetter, err error)
+}
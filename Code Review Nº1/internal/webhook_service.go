@@ -0,0 +1,34 @@
+package internal
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// ErrServiceWebhookNotFound is returned when a webhook is not found.
+	ErrServiceWebhookNotFound = errors.New("service: webhook not found")
+	// ErrServiceWebhookInvalid is returned when a webhook has invalid fields.
+	ErrServiceWebhookInvalid = errors.New("service: invalid webhook")
+	// ErrServiceDeadLetterNotFound is returned when a dead letter is not found.
+	ErrServiceDeadLetterNotFound = errors.New("service: dead letter not found")
+)
+
+// ServiceWebhook is the interface that wraps the basic methods for a webhook service.
+// - the vehicle events are delivered to the webhooks in background
+type ServiceWebhook interface {
+	// Create validates and stores a new webhook, generating its secret if missing
+	Create(ctx context.Context, w Webhook) (Webhook, error)
+	// FindAll returns all webhooks
+	FindAll(ctx context.Context) ([]Webhook, error)
+	// FindByID returns a webhook
+	FindByID(ctx context.Context, id int) (Webhook, error)
+	// DeleteByID removes a webhook
+	DeleteByID(ctx context.Context, id int) error
+	// FindAttempts returns the delivery log of a webhook, newest first
+	FindAttempts(ctx context.Context, webhookID int) ([]WebhookAttempt, error)
+	// FindDeadLetters returns the deliveries that exhausted their retries
+	FindDeadLetters(ctx context.Context) ([]WebhookDeadLetter, error)
+	// RetryDeadLetter schedules a dead letter for delivery again
+	RetryDeadLetter(ctx context.Context, id int) error
+}
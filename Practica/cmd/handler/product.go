@@ -3,7 +3,7 @@ package handler
 import (
 	"Practica/internal/domain"
 	"Practica/internal/product"
-	"Practica/pkg"
+	"errors"
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"strconv"
@@ -11,29 +11,34 @@ import (
 
 type ProductRouter struct {
 	productGroup *gin.RouterGroup
-	service      product.ProductService
-	repository   product.ProductRepository
+	service      *product.ProductService
 }
 
-func NewProductRouter(g *gin.RouterGroup) ProductRouter {
-	slice := pkg.FullfilDB("../../products.json")
-	repo := product.NewProductRepository(slice)
-
-	serv := product.NewProductService(repo)
-
-	return ProductRouter{productGroup: g, service: serv}
-
+func NewProductRouter(g *gin.RouterGroup, serv *product.ProductService) *ProductRouter {
+	return &ProductRouter{productGroup: g, service: serv}
 }
 
 func (r *ProductRouter) ProductRoutes() {
 	r.productGroup.GET("/ping", r.Ping())
 	r.productGroup.GET("/getAll", r.GetAllProducts())
 	r.productGroup.GET("/:id", r.GetById())
-	r.productGroup.GET("/search", r.GetByPrice())
+	r.productGroup.GET("/search", r.Search())
+	r.productGroup.GET("/export", r.Export())
+	r.productGroup.GET("/published", r.GetPublished())
+	r.productGroup.GET("/expiring", r.Expiring())
+	r.productGroup.GET("/expired", r.Expired())
+	r.productGroup.GET("/expired/value", r.ExpiredStock())
 	r.productGroup.POST("/", r.AddProduct())
-	r.productGroup.PUT("/", r.UpdateProduct())
-	r.productGroup.PATCH("/", r.UpdateProduct())
+	r.productGroup.POST("/import", r.Import())
+	r.productGroup.PUT("/:id", r.UpdateProduct())
+	r.productGroup.PATCH("/:id", r.PatchProduct())
 	r.productGroup.DELETE("/:id", r.DeleteProduct())
+	r.productGroup.POST("/:id/publish", r.Publish())
+	r.productGroup.POST("/:id/archive", r.Archive())
+	r.productGroup.POST("/:id/stock/in", r.MoveStock(domain.StockIn))
+	r.productGroup.POST("/:id/stock/out", r.MoveStock(domain.StockOut))
+	r.productGroup.POST("/:id/stock/adjust", r.MoveStock(domain.StockAdjust))
+	r.productGroup.GET("/:id/stock/movements", r.StockMovements())
 }
 
 func (r *ProductRouter) Ping() gin.HandlerFunc {
@@ -57,21 +62,49 @@ func (r *ProductRouter) GetById() gin.HandlerFunc {
 			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Id"})
 			return
 		}
-		data := r.service.GetById(id)
+		data, err := r.service.GetById(id)
+		if err != nil {
+			switch {
+			case errors.Is(err, product.ErrProductNotFound):
+				ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			default:
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get product"})
+			}
+			return
+		}
 		ctx.JSON(http.StatusOK, data)
 	}
 }
 
-func (r *ProductRouter) GetByPrice() gin.HandlerFunc {
+// Search returns the products matching the query parameters:
+// priceGt, priceGte, priceLte, quantityMin, is_published, status, name (substring), code_value (prefix),
+// expirationBefore and expirationAfter (dd/mm/yyyy), sortBy, order (asc or desc), offset and limit.
+func (r *ProductRouter) Search() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		price, err := strconv.ParseFloat(ctx.Query("priceGt"), 64)
+		query, err := parseProductQuery(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 
+		page, err := r.service.Search(query)
 		if err != nil {
-			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid priceGt parameter"})
+			switch {
+			case errors.Is(err, product.ErrInvalidQuery):
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			default:
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search products"})
+			}
 			return
 		}
-		data := r.service.GetByPrice(price)
-		ctx.JSON(http.StatusOK, data)
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"total":  page.Total,
+			"count":  len(page.Products),
+			"offset": query.Offset,
+			"limit":  query.Limit,
+			"data":   page.Products,
+		})
 	}
 }
 
@@ -80,7 +113,12 @@ func (r *ProductRouter) AddProduct() gin.HandlerFunc {
 		var newProduct domain.Product
 
 		if err := c.BindJSON(&newProduct); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
+			switch {
+			case errors.Is(err, domain.ErrInvalidDate):
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date format for expiration"})
+			default:
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
+			}
 			return
 		}
 
@@ -89,35 +127,55 @@ func (r *ProductRouter) AddProduct() gin.HandlerFunc {
 			return
 		}
 
-		if err := r.service.ValidateDateFormat(newProduct.Expiration); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		if err := r.service.AddProduct(&newProduct); err != nil {
+			switch {
+			case errors.Is(err, product.ErrCodeValueNotUnique):
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add product"})
+			}
 			return
 		}
 
-		if err := r.service.ValidateUniqueCodeValue(r.repository.GetAllProducts(), newProduct.CodeValue); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-			return
-		}
-
-		newProduct.ID = len(r.repository.GetAllProducts()) + 1
-		r.service.AddProduct(newProduct)
-
 		c.IndentedJSON(http.StatusCreated, newProduct)
 	}
 }
 
+// UpdateProduct replaces the product of the path, every field must be provided.
 func (r *ProductRouter) UpdateProduct() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Id"})
+			return
+		}
+
 		var updatedProduct domain.Product
 
 		if err := c.BindJSON(&updatedProduct); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
+			switch {
+			case errors.Is(err, domain.ErrInvalidDate):
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date format for expiration"})
+			default:
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
+			}
 			return
 		}
 
+		if updatedProduct.ID != 0 && updatedProduct.ID != id {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "id of the body doesn't match the path"})
+			return
+		}
+		updatedProduct.ID = id
+
 		// Validate and update the product
-		if err := r.service.UpdateProduct(updatedProduct); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		if err := r.service.UpdateProduct(&updatedProduct); err != nil {
+			switch {
+			case errors.Is(err, product.ErrProductNotFound):
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			default:
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			}
 			return
 		}
 
@@ -125,6 +183,41 @@ func (r *ProductRouter) UpdateProduct() gin.HandlerFunc {
 	}
 }
 
+// PatchProduct changes the provided fields of the product of the path.
+func (r *ProductRouter) PatchProduct() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Id"})
+			return
+		}
+
+		var patch domain.ProductPatch
+		if err := c.ShouldBindJSON(&patch); err != nil {
+			switch {
+			case errors.Is(err, domain.ErrInvalidDate):
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date format for expiration"})
+			default:
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
+			}
+			return
+		}
+
+		data, err := r.service.PatchProduct(id, patch)
+		if err != nil {
+			switch {
+			case errors.Is(err, product.ErrProductNotFound):
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			default:
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			}
+			return
+		}
+
+		c.IndentedJSON(http.StatusOK, data)
+	}
+}
+
 func (r *ProductRouter) DeleteProduct() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		id, err := strconv.Atoi(c.Param("id"))
@@ -134,7 +227,12 @@ func (r *ProductRouter) DeleteProduct() gin.HandlerFunc {
 		}
 
 		if err := r.service.DeleteProduct(id); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			switch {
+			case errors.Is(err, product.ErrProductNotFound):
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete product"})
+			}
 			return
 		}
 
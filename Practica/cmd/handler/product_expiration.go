@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"Practica/internal/domain"
+	"Practica/internal/product"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultExpiringDays is the window of GET /products/expiring without days.
+const DefaultExpiringDays = 7
+
+// Expiring returns the products that expire from today to ?days= later (7 by default).
+func (r *ProductRouter) Expiring() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		days := DefaultExpiringDays
+		if raw, ok := ctx.GetQuery("days"); ok {
+			var err error
+			if days, err = strconv.Atoi(raw); err != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid days parameter"})
+				return
+			}
+		}
+
+		data, err := r.service.Expiring(domain.NewDate(time.Now()), days)
+		if err != nil {
+			switch {
+			case errors.Is(err, product.ErrInvalidQuery):
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			default:
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get expiring products"})
+			}
+			return
+		}
+		ctx.JSON(http.StatusOK, data)
+	}
+}
+
+// Expired returns the products already expired.
+func (r *ProductRouter) Expired() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		data, err := r.service.Expired(domain.NewDate(time.Now()))
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get expired products"})
+			return
+		}
+		ctx.JSON(http.StatusOK, data)
+	}
+}
+
+// ExpiredStock returns the quantity and value of the stock of the expired products.
+func (r *ProductRouter) ExpiredStock() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		data, err := r.service.ExpiredStock(domain.NewDate(time.Now()))
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get expired stock"})
+			return
+		}
+		ctx.JSON(http.StatusOK, data)
+	}
+}
@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"Practica/internal/domain"
+	"Practica/internal/product"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MaxImportSize is the maximum size of the body of an import.
+const MaxImportSize = 10 << 20
+
+// Import upserts the products of a csv (Content-Type text/csv) or json array body by code_value,
+// reporting the outcome of each row.
+func (r *ProductRouter) Import() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body := http.MaxBytesReader(c.Writer, c.Request.Body, MaxImportSize)
+
+		var rows []product.ImportRow
+		var err error
+		switch contentType := c.ContentType(); contentType {
+		case "text/csv":
+			rows, err = product.ReadCSV(body)
+		case "application/json", "":
+			rows, err = readJSONRows(body)
+		default:
+			c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": fmt.Sprintf("unsupported content type %q, use text/csv or application/json", contentType)})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		results, err := r.service.ImportProducts(rows)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import products", "results": results})
+			return
+		}
+
+		summary := map[string]int{product.ImportCreated: 0, product.ImportUpdated: 0, product.ImportFailed: 0}
+		for _, res := range results {
+			summary[res.Action]++
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"created": summary[product.ImportCreated],
+			"updated": summary[product.ImportUpdated],
+			"failed":  summary[product.ImportFailed],
+			"results": results,
+		})
+	}
+}
+
+// readJSONRows reads the rows of a json array, the errors of each element are kept in the row.
+func readJSONRows(body io.Reader) ([]product.ImportRow, error) {
+	var raw []json.RawMessage
+	if err := json.NewDecoder(body).Decode(&raw); err != nil {
+		return nil, errors.New("Invalid JSON, expected an array of products")
+	}
+	rows := make([]product.ImportRow, len(raw))
+	for i, m := range raw {
+		if err := json.Unmarshal(m, &rows[i].Product); err != nil {
+			if errors.Is(err, domain.ErrInvalidDate) {
+				rows[i].Err = errors.New("invalid date format for expiration")
+			} else {
+				rows[i].Err = errors.New("invalid product")
+			}
+			// keep only the code_value, to report it
+			var ref struct {
+				CodeValue string `json:"code_value"`
+			}
+			if err := json.Unmarshal(m, &ref); err != nil {
+				rows[i].Err = fmt.Errorf("%w, code_value can't be read", rows[i].Err)
+			}
+			rows[i].Product = domain.Product{CodeValue: ref.CodeValue}
+		}
+	}
+	return rows, nil
+}
+
+// Export streams all the products as ?format=json (default) or csv.
+// The status is sent before the products, so a failed write stops the export and is only recorded in the errors of the context.
+func (r *ProductRouter) Export() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		format := strings.ToLower(c.DefaultQuery("format", "json"))
+		if format != "json" && format != "csv" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format parameter, use csv or json"})
+			return
+		}
+		products := r.service.GetAllProducts()
+
+		c.Header("Content-Disposition", "attachment; filename=products."+format)
+		c.Status(http.StatusOK)
+		var err error
+		switch format {
+		case "csv":
+			c.Header("Content-Type", "text/csv; charset=utf-8")
+			err = writeCSV(c.Writer, products)
+		case "json":
+			c.Header("Content-Type", "application/json; charset=utf-8")
+			err = writeJSON(c.Writer, products)
+		}
+		if err != nil {
+			c.Error(fmt.Errorf("export of the products stopped: %w", err))
+			c.Abort()
+		}
+	}
+}
+
+// writeCSV writes the products as csv with a header, stopping at the first error.
+func writeCSV(out io.Writer, products []domain.Product) error {
+	w := csv.NewWriter(out)
+	if err := w.Write(product.CSVHeader); err != nil {
+		return err
+	}
+	for _, p := range products {
+		if err := w.Write(product.CSVRecord(p)); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// writeJSON writes the products as a json array, one per line, stopping at the first error.
+func writeJSON(out io.Writer, products []domain.Product) error {
+	enc := json.NewEncoder(out)
+	if _, err := io.WriteString(out, "["); err != nil {
+		return err
+	}
+	for i, p := range products {
+		if i > 0 {
+			if _, err := io.WriteString(out, ","); err != nil {
+				return err
+			}
+		}
+		if err := enc.Encode(p); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(out, "]\n")
+	return err
+}
@@ -0,0 +1,84 @@
+package handler_test
+
+import (
+	"Practica/cmd/handler"
+	"Practica/internal/domain"
+	"Practica/internal/product"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestProductRouter_Import_UnreadableCodeValue(t *testing.T) {
+	server := newServer(nil)
+
+	res := do(server, http.MethodPost, "/products/import",
+		`[{"name":"Milk","quantity":1,"code_value":"MLK1","expiration":"31/12/2030","price":1}, 5, {"code_value":7}]`)
+
+	if res.Code != http.StatusOK {
+		t.Fatalf("POST /products/import = %d %s, want %d", res.Code, res.Body, http.StatusOK)
+	}
+	var body struct {
+		Created int                    `json:"created"`
+		Failed  int                    `json:"failed"`
+		Results []product.ImportResult `json:"results"`
+	}
+	if err := json.Unmarshal(res.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decoding %q: %v", res.Body, err)
+	}
+	if body.Created != 1 || body.Failed != 2 {
+		t.Fatalf("created %d and failed %d, want 1 and 2", body.Created, body.Failed)
+	}
+	for _, r := range body.Results[1:] {
+		if r.Action != product.ImportFailed || !strings.Contains(r.Error, "code_value can't be read") {
+			t.Errorf("row %d = %+v, want failed because its code_value can't be read", r.Row, r)
+		}
+	}
+}
+
+// failingWriter is a response writer whose writes fail.
+type failingWriter struct {
+	header http.Header
+	writes int
+}
+
+func (w *failingWriter) Header() http.Header { return w.header }
+
+func (w *failingWriter) WriteHeader(int) {}
+
+func (w *failingWriter) Write([]byte) (int, error) {
+	w.writes++
+	return 0, errors.New("connection reset by peer")
+}
+
+func TestProductRouter_Export_WriteError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	prods := make([]domain.Product, 100)
+	for i := range prods {
+		prods[i] = domain.Product{ID: i + 1, Name: "Milk", Quantity: 1, CodeValue: fmt.Sprintf("MLK%d", i+1), Price: 1}
+	}
+	serv := product.NewProductService(product.NewProductMemory(prods), product.NewStockMovementMemory())
+
+	for _, format := range []string{"json", "csv"} {
+		t.Run(format, func(t *testing.T) {
+			w := &failingWriter{header: http.Header{}}
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/products/export?format="+format, nil)
+
+			handler.NewProductRouter(nil, serv).Export()(c)
+
+			if len(c.Errors) != 1 {
+				t.Fatalf("errors = %v, want the write error", c.Errors)
+			}
+			if w.writes != 1 {
+				t.Fatalf("writes = %d, want the export stopped at the first failed write", w.writes)
+			}
+		})
+	}
+}
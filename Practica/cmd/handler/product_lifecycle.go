@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"Practica/internal/domain"
+	"Practica/internal/product"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Publish makes a draft product public.
+func (r *ProductRouter) Publish() gin.HandlerFunc {
+	return r.transition(func(id int) (domain.Product, error) {
+		return r.service.Publish(id, domain.NewDate(time.Now()))
+	})
+}
+
+// Archive retires a product.
+func (r *ProductRouter) Archive() gin.HandlerFunc {
+	return r.transition(r.service.Archive)
+}
+
+// transition returns the handler of a lifecycle action over the product of the path.
+func (r *ProductRouter) transition(action func(id int) (domain.Product, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Id"})
+			return
+		}
+
+		data, err := action(id)
+		if err != nil {
+			switch {
+			case errors.Is(err, product.ErrProductNotFound):
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			case errors.Is(err, product.ErrInvalidTransition), errors.Is(err, product.ErrProductExpired):
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change product status"})
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, data)
+	}
+}
+
+// GetPublished returns the published products, it takes the query parameters of Search.
+func (r *ProductRouter) GetPublished() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		query, err := parseProductQuery(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		page, err := r.service.GetPublished(query)
+		if err != nil {
+			switch {
+			case errors.Is(err, product.ErrInvalidQuery):
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			default:
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get published products"})
+			}
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"total":  page.Total,
+			"count":  len(page.Products),
+			"offset": query.Offset,
+			"limit":  query.Limit,
+			"data":   page.Products,
+		})
+	}
+}
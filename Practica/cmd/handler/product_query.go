@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"Practica/internal/domain"
+	"Practica/internal/product"
+	"fmt"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseProductQuery reads the search of the products from the query parameters.
+func parseProductQuery(ctx *gin.Context) (q domain.ProductQuery, err error) {
+	float := func(name string) (*float64, error) {
+		raw, ok := ctx.GetQuery(name)
+		if !ok {
+			return nil, nil
+		}
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid %s parameter", name)
+		}
+		return &v, nil
+	}
+	date := func(name string) (*domain.Date, error) {
+		raw, ok := ctx.GetQuery(name)
+		if !ok {
+			return nil, nil
+		}
+		v, err := domain.ParseDate(raw)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid %s parameter", name)
+		}
+		return &v, nil
+	}
+
+	if q.PriceGt, err = float("priceGt"); err != nil {
+		return
+	}
+	if q.PriceGte, err = float("priceGte"); err != nil {
+		return
+	}
+	if q.PriceLte, err = float("priceLte"); err != nil {
+		return
+	}
+	if raw, ok := ctx.GetQuery("quantityMin"); ok {
+		v, convErr := strconv.Atoi(raw)
+		if convErr != nil {
+			return q, fmt.Errorf("Invalid quantityMin parameter")
+		}
+		q.QuantityMin = &v
+	}
+	if raw, ok := ctx.GetQuery("is_published"); ok {
+		v, convErr := strconv.ParseBool(raw)
+		if convErr != nil {
+			return q, fmt.Errorf("Invalid is_published parameter")
+		}
+		q.IsPublished = &v
+	}
+	q.Status = ctx.Query("status")
+	q.Name = ctx.Query("name")
+	q.CodeValuePrefix = ctx.Query("code_value")
+	if q.ExpirationBefore, err = date("expirationBefore"); err != nil {
+		return
+	}
+	if q.ExpirationAfter, err = date("expirationAfter"); err != nil {
+		return
+	}
+
+	q.SortBy = ctx.Query("sortBy")
+	switch order := ctx.DefaultQuery("order", "asc"); order {
+	case "asc":
+	case "desc":
+		q.Descending = true
+	default:
+		return q, fmt.Errorf("Invalid order parameter")
+	}
+	if raw, ok := ctx.GetQuery("offset"); ok {
+		if q.Offset, err = strconv.Atoi(raw); err != nil {
+			return q, fmt.Errorf("Invalid offset parameter")
+		}
+	}
+	q.Limit = product.DefaultSearchLimit
+	if raw, ok := ctx.GetQuery("limit"); ok {
+		if q.Limit, err = strconv.Atoi(raw); err != nil || q.Limit < 1 {
+			return q, fmt.Errorf("Invalid limit parameter")
+		}
+	}
+	return q, nil
+}
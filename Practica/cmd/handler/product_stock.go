@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"Practica/internal/product"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StockMovementRequest is the body of the stock movements.
+type StockMovementRequest struct {
+	Quantity int    `json:"quantity"`
+	Reason   string `json:"reason"`
+}
+
+// MoveStock records a movement of the given kind for the product of the path.
+func (r *ProductRouter) MoveStock(kind string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Id"})
+			return
+		}
+		var body StockMovementRequest
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
+			return
+		}
+
+		movement, err := r.service.MoveStock(id, kind, body.Quantity, body.Reason)
+		if err != nil {
+			switch {
+			case errors.Is(err, product.ErrProductNotFound):
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			case errors.Is(err, product.ErrInvalidMovement):
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			case errors.Is(err, product.ErrInsufficientStock):
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to move stock"})
+			}
+			return
+		}
+
+		c.JSON(http.StatusCreated, movement)
+	}
+}
+
+// StockMovements returns the stock movements of the product, oldest first.
+func (r *ProductRouter) StockMovements() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Id"})
+			return
+		}
+
+		data, err := r.service.Movements(id)
+		if err != nil {
+			switch {
+			case errors.Is(err, product.ErrProductNotFound):
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get stock movements"})
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, data)
+	}
+}
@@ -0,0 +1,267 @@
+package handler_test
+
+import (
+	"Practica/cmd/handler"
+	"Practica/internal/domain"
+	"Practica/internal/product"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newServer returns the product routes over an in-memory repository with prods.
+func newServer(prods []domain.Product) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	serv := product.NewProductService(product.NewProductMemory(prods), product.NewStockMovementMemory())
+	server := gin.New()
+	handler.NewProductRouter(server.Group("/products"), serv).ProductRoutes()
+	return server
+}
+
+// do serves a request with an optional json body.
+func do(server *gin.Engine, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	res := httptest.NewRecorder()
+	server.ServeHTTP(res, req)
+	return res
+}
+
+// decodeProduct decodes the product of a response body.
+func decodeProduct(t *testing.T, res *httptest.ResponseRecorder) domain.Product {
+	t.Helper()
+	var p domain.Product
+	if err := json.Unmarshal(res.Body.Bytes(), &p); err != nil {
+		t.Fatalf("decoding %q: %v", res.Body, err)
+	}
+	return p
+}
+
+func TestProductRouter_RoundTrip(t *testing.T) {
+	server := newServer([]domain.Product{
+		{ID: 1, Name: "Oil", Quantity: 3, CodeValue: "OIL1", Status: domain.ProductDraft, Price: 10},
+	})
+
+	// create
+	res := do(server, http.MethodPost, "/products/",
+		`{"name":"Milk","quantity":10,"code_value":"MLK1","expiration":"31/12/2030","price":2.5}`)
+	if res.Code != http.StatusCreated {
+		t.Fatalf("POST /products/ = %d %s, want %d", res.Code, res.Body, http.StatusCreated)
+	}
+	created := decodeProduct(t, res)
+	if created.ID != 2 || created.Name != "Milk" || created.Status != domain.ProductDraft {
+		t.Fatalf("created = %+v, want id 2, name Milk and status draft", created)
+	}
+	path := "/products/2"
+
+	// get
+	res = do(server, http.MethodGet, path, "")
+	if res.Code != http.StatusOK || decodeProduct(t, res) != created {
+		t.Fatalf("GET %s = %d %s, want the created product", path, res.Code, res.Body)
+	}
+
+	// replace
+	res = do(server, http.MethodPut, path,
+		`{"name":"Whole milk","quantity":10,"code_value":"MLK1","expiration":"30/11/2030","price":3}`)
+	if res.Code != http.StatusOK {
+		t.Fatalf("PUT %s = %d %s, want %d", path, res.Code, res.Body, http.StatusOK)
+	}
+	if got := decodeProduct(t, res); got.Name != "Whole milk" || got.Price != 3 || got.Expiration.String() != "30/11/2030" {
+		t.Fatalf("PUT %s = %+v, want the replaced fields", path, got)
+	}
+
+	// patch
+	res = do(server, http.MethodPatch, path, `{"price":4.5}`)
+	if res.Code != http.StatusOK {
+		t.Fatalf("PATCH %s = %d %s, want %d", path, res.Code, res.Body, http.StatusOK)
+	}
+	if got := decodeProduct(t, res); got.Price != 4.5 || got.Name != "Whole milk" {
+		t.Fatalf("PATCH %s = %+v, want only the price changed", path, got)
+	}
+
+	// delete
+	res = do(server, http.MethodDelete, path, "")
+	if res.Code != http.StatusOK || !strings.Contains(res.Body.String(), "Product deleted successfully") {
+		t.Fatalf("DELETE %s = %d %s, want %d", path, res.Code, res.Body, http.StatusOK)
+	}
+	res = do(server, http.MethodGet, path, "")
+	if res.Code != http.StatusNotFound {
+		t.Fatalf("GET %s after delete = %d %s, want %d", path, res.Code, res.Body, http.StatusNotFound)
+	}
+}
+
+func TestProductRouter_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		code   int
+	}{
+		{name: "create with invalid json", method: http.MethodPost, path: "/products/", body: `{"name":`, code: http.StatusBadRequest},
+		{name: "create with missing fields", method: http.MethodPost, path: "/products/", body: `{"name":"Milk"}`, code: http.StatusBadRequest},
+		{name: "create with duplicated code value", method: http.MethodPost, path: "/products/",
+			body: `{"name":"Milk","quantity":1,"code_value":"OIL1","expiration":"31/12/2030","price":1}`, code: http.StatusBadRequest},
+		{name: "get with invalid id", method: http.MethodGet, path: "/products/abc", code: http.StatusBadRequest},
+		{name: "get missing", method: http.MethodGet, path: "/products/99", code: http.StatusNotFound},
+		{name: "replace missing", method: http.MethodPut, path: "/products/99",
+			body: `{"name":"Milk","quantity":1,"code_value":"MLK1","expiration":"31/12/2030","price":1}`, code: http.StatusNotFound},
+		{name: "replace with another id", method: http.MethodPut, path: "/products/1",
+			body: `{"id":2,"name":"Milk","quantity":1,"code_value":"MLK1","expiration":"31/12/2030","price":1}`, code: http.StatusBadRequest},
+		{name: "patch missing", method: http.MethodPatch, path: "/products/99", body: `{"price":1}`, code: http.StatusNotFound},
+		{name: "patch without fields", method: http.MethodPatch, path: "/products/1", body: `{}`, code: http.StatusBadRequest},
+		{name: "patch with negative quantity", method: http.MethodPatch, path: "/products/1", body: `{"quantity":-1}`, code: http.StatusBadRequest},
+		{name: "patch with negative price", method: http.MethodPatch, path: "/products/1", body: `{"price":-1}`, code: http.StatusBadRequest},
+		{name: "replace with negative price", method: http.MethodPut, path: "/products/1",
+			body: `{"name":"Oil","quantity":3,"code_value":"OIL1","expiration":"31/12/2030","price":-1}`, code: http.StatusBadRequest},
+		{name: "delete missing", method: http.MethodDelete, path: "/products/99", code: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newServer([]domain.Product{
+				{ID: 1, Name: "Oil", Quantity: 3, CodeValue: "OIL1", Status: domain.ProductDraft, Price: 10},
+			})
+
+			res := do(server, tt.method, tt.path, tt.body)
+
+			if res.Code != tt.code {
+				t.Fatalf("%s %s = %d %s, want %d", tt.method, tt.path, res.Code, res.Body, tt.code)
+			}
+		})
+	}
+}
+
+func TestProductRouter_Search(t *testing.T) {
+	prods := make([]domain.Product, product.DefaultSearchLimit+5)
+	for i := range prods {
+		prods[i] = domain.Product{ID: i + 1, Name: "Oil", Quantity: i, CodeValue: fmt.Sprintf("OIL%d", i+1), Status: domain.ProductDraft, Price: float64(i + 1)}
+	}
+	server := newServer(prods)
+
+	tests := []struct {
+		name  string
+		query string
+		code  int
+		// want are the total, count, offset and limit of the page
+		want [4]int
+	}{
+		{name: "default limit", query: "", code: http.StatusOK, want: [4]int{25, 20, 0, 20}},
+		{name: "maximum limit", query: "?limit=100", code: http.StatusOK, want: [4]int{25, 25, 0, 100}},
+		{name: "filter, sort and page", query: "?priceGte=5&priceLte=10&sortBy=price&order=desc&offset=2&limit=3", code: http.StatusOK, want: [4]int{6, 3, 2, 3}},
+		{name: "offset past the end", query: "?offset=30", code: http.StatusOK, want: [4]int{25, 0, 30, 20}},
+		{name: "zero limit", query: "?limit=0", code: http.StatusBadRequest},
+		{name: "limit above the maximum", query: "?limit=101", code: http.StatusBadRequest},
+		{name: "priceGte greater than priceLte", query: "?priceGte=10&priceLte=5", code: http.StatusBadRequest},
+		{name: "unknown sort field", query: "?sortBy=weight", code: http.StatusBadRequest},
+		{name: "unknown order", query: "?order=up", code: http.StatusBadRequest},
+		{name: "negative offset", query: "?offset=-1", code: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := do(server, http.MethodGet, "/products/search"+tt.query, "")
+
+			if res.Code != tt.code {
+				t.Fatalf("GET /products/search%s = %d %s, want %d", tt.query, res.Code, res.Body, tt.code)
+			}
+			if tt.code != http.StatusOK {
+				return
+			}
+			var page struct {
+				Total, Count, Offset, Limit int
+				Data                        []domain.Product
+			}
+			if err := json.Unmarshal(res.Body.Bytes(), &page); err != nil {
+				t.Fatalf("decoding %q: %v", res.Body, err)
+			}
+			if got := [4]int{page.Total, page.Count, page.Offset, page.Limit}; got != tt.want || len(page.Data) != page.Count {
+				t.Fatalf("page = %v with %d products, want %v", got, len(page.Data), tt.want)
+			}
+		})
+	}
+}
+
+func TestProductRouter_Lifecycle(t *testing.T) {
+	future := domain.NewDate(time.Now().AddDate(1, 0, 0))
+	server := newServer([]domain.Product{
+		{ID: 1, Name: "Milk", Quantity: 1, CodeValue: "MLK1", Status: domain.ProductDraft, Expiration: future, Price: 2.5},
+		{ID: 2, Name: "Oil", Quantity: 1, CodeValue: "OIL1", Status: domain.ProductDraft, Expiration: future, Price: 10},
+		{ID: 3, Name: "Bread", Quantity: 1, CodeValue: "BRD1", Status: domain.ProductDraft, Price: 1.2,
+			Expiration: domain.NewDate(time.Now().AddDate(0, 0, -1))},
+		{ID: 4, Name: "Eggs", Quantity: 1, CodeValue: "EGG1", Status: domain.ProductDraft, Expiration: future, Price: 3},
+	})
+
+	steps := []struct {
+		path string
+		code int
+	}{
+		{path: "/products/1/publish", code: http.StatusOK},
+		{path: "/products/1/publish", code: http.StatusConflict},
+		{path: "/products/2/publish", code: http.StatusOK},
+		{path: "/products/2/archive", code: http.StatusOK},
+		{path: "/products/2/publish", code: http.StatusConflict},
+		{path: "/products/2/archive", code: http.StatusConflict},
+		// expired
+		{path: "/products/3/publish", code: http.StatusConflict},
+		{path: "/products/99/publish", code: http.StatusNotFound},
+		{path: "/products/abc/archive", code: http.StatusBadRequest},
+	}
+	for _, s := range steps {
+		if res := do(server, http.MethodPost, s.path, ""); res.Code != s.code {
+			t.Fatalf("POST %s = %d %s, want %d", s.path, res.Code, res.Body, s.code)
+		}
+	}
+
+	// only the published product is listed, not the drafts nor the archived one
+	res := do(server, http.MethodGet, "/products/published", "")
+	var page struct {
+		Total int
+		Data  []domain.Product
+	}
+	if err := json.Unmarshal(res.Body.Bytes(), &page); res.Code != http.StatusOK || err != nil {
+		t.Fatalf("GET /products/published = %d %s, want %d", res.Code, res.Body, http.StatusOK)
+	}
+	if page.Total != 1 || len(page.Data) != 1 || page.Data[0].ID != 1 || !page.Data[0].IsPublished {
+		t.Fatalf("GET /products/published = %+v, want the published product 1", page)
+	}
+}
+
+func TestProductRouter_IDsNotReused(t *testing.T) {
+	server := newServer([]domain.Product{
+		{ID: 4, Name: "Oil", Quantity: 3, CodeValue: "OIL1", Status: domain.ProductDraft, Price: 10},
+	})
+	create := func(codeValue string) int {
+		t.Helper()
+		res := do(server, http.MethodPost, "/products/",
+			`{"name":"Milk","quantity":10,"code_value":"`+codeValue+`","expiration":"31/12/2030","price":2.5}`)
+		if res.Code != http.StatusCreated {
+			t.Fatalf("POST /products/ = %d %s, want %d", res.Code, res.Body, http.StatusCreated)
+		}
+		return decodeProduct(t, res).ID
+	}
+
+	if id := create("MLK1"); id != 5 {
+		t.Fatalf("created id = %d, want 5", id)
+	}
+	if res := do(server, http.MethodDelete, "/products/5", ""); res.Code != http.StatusOK {
+		t.Fatalf("DELETE /products/5 = %d %s, want %d", res.Code, res.Body, http.StatusOK)
+	}
+
+	// the deleted product is gone
+	for _, method := range []string{http.MethodGet, http.MethodDelete} {
+		if res := do(server, method, "/products/5", ""); res.Code != http.StatusNotFound {
+			t.Fatalf("%s /products/5 after delete = %d %s, want %d", method, res.Code, res.Body, http.StatusNotFound)
+		}
+	}
+	// and its id is not given to the next product
+	if id := create("MLK2"); id != 6 {
+		t.Fatalf("id after delete = %d, want 6", id)
+	}
+}
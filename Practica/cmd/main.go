@@ -2,24 +2,112 @@ package main
 
 import (
 	"Practica/cmd/handler"
+	"Practica/internal/product"
+	"Practica/pkg"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 )
 
 func init() {
-	if err := godotenv.Load(); err != nil {
-		panic("Error loading .env file")
+	// the .env file is optional, the variables can come from the environment
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.Printf("could not load .env file: %v", err)
 	}
 }
 
+// newRepository returns the repository selected by PRODUCTS_STORAGE:
+// "memory" (default) keeps the changes in memory, "file" also writes them back to PRODUCTS_FILE.
+// The products are loaded from PRODUCTS_FILE (products.json by default). With PRODUCTS_STRICT=true
+// any error of the file fails the start, otherwise the invalid products are skipped
+// and a file that can't be read leaves the repository empty.
+func newRepository() (product.ProductRepository, error) {
+	path := os.Getenv("PRODUCTS_FILE")
+	if path == "" {
+		path = "products.json"
+	}
+	strict := false
+	if raw := os.Getenv("PRODUCTS_STRICT"); raw != "" {
+		var err error
+		if strict, err = strconv.ParseBool(raw); err != nil {
+			return nil, fmt.Errorf("invalid PRODUCTS_STRICT %q", raw)
+		}
+	}
+	storage := os.Getenv("PRODUCTS_STORAGE")
+	if storage != "" && storage != "memory" && storage != "file" {
+		return nil, fmt.Errorf("unknown PRODUCTS_STORAGE %q", storage)
+	}
+
+	slice, err := pkg.LoadProducts(path)
+	switch {
+	case err == nil:
+	case strict:
+		return nil, fmt.Errorf("could not load products (PRODUCTS_STRICT is set):\n%w", err)
+	case storage == "file" && !errors.Is(err, fs.ErrNotExist):
+		// writing back would drop the products that couldn't be loaded
+		return nil, fmt.Errorf("could not load products, fix %s before using it as storage:\n%w", path, err)
+	default:
+		log.Printf("starting with the %d products that could be loaded:\n%v", len(slice), err)
+	}
+
+	if storage == "file" {
+		return product.NewProductFile(path, slice), nil
+	}
+	return product.NewProductMemory(slice), nil
+}
+
 func main() {
+	// ctx is cancelled on shutdown, stopping the background jobs and the server
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	repo, err := newRepository()
+	if err != nil {
+		log.Fatal(err)
+	}
+	serv := product.NewProductService(repo, product.NewStockMovementMemory())
+
+	// UNPUBLISH_EXPIRED_INTERVAL (e.g. "1h") enables unpublishing the expired products in the background
+	if raw := os.Getenv("UNPUBLISH_EXPIRED_INTERVAL"); raw != "" {
+		interval, err := time.ParseDuration(raw)
+		if err != nil || interval <= 0 {
+			log.Fatalf("invalid UNPUBLISH_EXPIRED_INTERVAL %q", raw)
+		}
+		go product.NewExpirationScheduler(serv, interval).Run(ctx)
+	}
+
 	server := gin.Default()
 
 	group := server.Group("/products")
 
-	router := handler.NewProductRouter(group)
+	router := handler.NewProductRouter(group, serv)
 
 	router.ProductRoutes()
 
-	server.Run(":8080")
+	srv := &http.Server{Addr: ":8080", Handler: server}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	}()
+
+	<-ctx.Done()
+	stop()
+	// let the requests in flight finish
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("shutdown: %v", err)
+	}
 }
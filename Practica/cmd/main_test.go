@@ -0,0 +1,75 @@
+package main
+
+import (
+	"Practica/internal/product"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewRepository(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "valid.json")
+	partial := filepath.Join(dir, "partial.json")
+	broken := filepath.Join(dir, "broken.json")
+	for path, content := range map[string]string{
+		valid: `[{"id":1,"name":"Milk","quantity":10,"code_value":"MLK1","expiration":"31/12/2030","price":2.5}]`,
+		// the second product has no name
+		partial: `[{"id":1,"name":"Milk","quantity":10,"code_value":"MLK1","expiration":"31/12/2030","price":2.5},
+			{"id":2,"name":"","quantity":3,"code_value":"OIL1","expiration":"31/12/2030","price":10}]`,
+		broken: `{"id":1}`,
+	} {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	missing := filepath.Join(dir, "missing.json")
+
+	tests := []struct {
+		name    string
+		file    string
+		strict  string
+		storage string
+		// want is the number of products loaded
+		want     int
+		wantFile bool
+		wantErr  bool
+	}{
+		{name: "valid", file: valid, want: 1},
+		{name: "valid, strict", file: valid, strict: "true", want: 1},
+		{name: "valid, file storage", file: valid, storage: "file", want: 1, wantFile: true},
+		{name: "partial skips the invalid products", file: partial, want: 1},
+		{name: "partial, strict", file: partial, strict: "true", wantErr: true},
+		{name: "partial, explicitly not strict", file: partial, strict: "false", want: 1},
+		{name: "partial, file storage", file: partial, storage: "file", wantErr: true},
+		{name: "broken starts empty", file: broken, want: 0},
+		{name: "broken, strict", file: broken, strict: "1", wantErr: true},
+		{name: "missing starts empty", file: missing, want: 0},
+		{name: "missing, strict", file: missing, strict: "true", wantErr: true},
+		{name: "missing, file storage", file: missing, storage: "file", want: 0, wantFile: true},
+		{name: "invalid strict", file: valid, strict: "yes please", wantErr: true},
+		{name: "unknown storage", file: valid, storage: "sql", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("PRODUCTS_FILE", tt.file)
+			t.Setenv("PRODUCTS_STRICT", tt.strict)
+			t.Setenv("PRODUCTS_STORAGE", tt.storage)
+
+			repo, err := newRepository()
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newRepository() error = %v, want error %t", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if _, isFile := repo.(*product.ProductFile); isFile != tt.wantFile {
+				t.Fatalf("newRepository() = %T, want a file repository %t", repo, tt.wantFile)
+			}
+			if got := len(repo.GetAllProducts()); got != tt.want {
+				t.Fatalf("newRepository() has %d products, want %d", got, tt.want)
+			}
+		})
+	}
+}
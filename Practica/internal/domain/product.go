@@ -1,11 +1,104 @@
 package domain
 
+import "errors"
+
+var (
+	ErrProductFieldsMissing   = errors.New("all fields except is_published and status must be provided")
+	ErrProductPatchEmpty      = errors.New("at least one field must be provided")
+	ErrProductFieldsEmpty     = errors.New("provided fields can't be empty")
+	ErrProductQuantityInvalid = errors.New("quantity can't be negative")
+	ErrProductPriceInvalid    = errors.New("price must be greater than 0")
+)
+
 type Product struct {
-	ID          int     `json:"id"`
-	Name        string  `json:"name"`
-	Quantity    int     `json:"quantity"`
-	CodeValue   string  `json:"code_value"`
-	IsPublished bool    `json:"is_published"`
-	Expiration  string  `json:"expiration"`
-	Price       float64 `json:"price"`
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	Quantity  int    `json:"quantity"`
+	CodeValue string `json:"code_value"`
+	// IsPublished mirrors Status, it is kept for the clients of the flag.
+	IsPublished bool `json:"is_published"`
+	// Status is the state of the lifecycle, changed only by its actions.
+	Status     string  `json:"status"`
+	Expiration Date    `json:"expiration"`
+	Price      float64 `json:"price"`
+}
+
+// Validate checks that the fields of the product are provided and valid.
+// A quantity of 0 is valid, it is the stock of a product sold out.
+// It is shared by the service and the loader of the products file.
+func (p Product) Validate() error {
+	if p.Name == "" || p.CodeValue == "" ||
+		p.Expiration.IsZero() || p.Price == 0.0 {
+		return ErrProductFieldsMissing
+	}
+	if err := validateQuantity(p.Quantity); err != nil {
+		return err
+	}
+	return validatePrice(p.Price)
+}
+
+// validateQuantity checks the rule of the quantity shared by products and patches.
+func validateQuantity(quantity int) error {
+	if quantity < 0 {
+		return ErrProductQuantityInvalid
+	}
+	return nil
+}
+
+// validatePrice checks the rule of the price shared by products and patches.
+func validatePrice(price float64) error {
+	if price <= 0 {
+		return ErrProductPriceInvalid
+	}
+	return nil
+}
+
+// ProductPatch is a partial update of a product, nil fields are kept.
+// The status is changed only by the lifecycle actions.
+type ProductPatch struct {
+	Name       *string  `json:"name"`
+	Quantity   *int     `json:"quantity"`
+	CodeValue  *string  `json:"code_value"`
+	Expiration *Date    `json:"expiration"`
+	Price      *float64 `json:"price"`
+}
+
+// Validate checks that the patch changes at least one field and that the provided fields are valid,
+// with the same rules as Product.Validate.
+func (p ProductPatch) Validate() error {
+	if p == (ProductPatch{}) {
+		return ErrProductPatchEmpty
+	}
+	if p.Name != nil && *p.Name == "" ||
+		p.CodeValue != nil && *p.CodeValue == "" ||
+		p.Expiration != nil && p.Expiration.IsZero() ||
+		p.Price != nil && *p.Price == 0.0 {
+		return ErrProductFieldsEmpty
+	}
+	if p.Quantity != nil {
+		if err := validateQuantity(*p.Quantity); err != nil {
+			return err
+		}
+	}
+	if p.Price != nil {
+		return validatePrice(*p.Price)
+	}
+	return nil
+}
+
+// Expired reports whether the product expired before the day today.
+func (p Product) Expired(today Date) bool {
+	return p.Expiration.Before(today.Time)
+}
+
+// StockValue is the value of the units in stock of the product.
+func (p Product) StockValue() float64 {
+	return float64(p.Quantity) * p.Price
+}
+
+// ExpiredStockReport is the stock of the expired products.
+type ExpiredStockReport struct {
+	Count    int     `json:"count"`
+	Quantity int     `json:"quantity"`
+	Value    float64 `json:"value"`
 }
@@ -0,0 +1,43 @@
+package domain
+
+// Fields of Product a search can be sorted by.
+const (
+	ProductSortID          = "id"
+	ProductSortName        = "name"
+	ProductSortQuantity    = "quantity"
+	ProductSortCodeValue   = "code_value"
+	ProductSortIsPublished = "is_published"
+	ProductSortStatus      = "status"
+	ProductSortExpiration  = "expiration"
+	ProductSortPrice       = "price"
+)
+
+// ProductQuery is the search of the products, nil and zero values don't filter.
+type ProductQuery struct {
+	PriceGt     *float64
+	PriceGte    *float64
+	PriceLte    *float64
+	QuantityMin *int
+	IsPublished *bool
+	Status      string
+	// Name is matched as a case insensitive substring.
+	Name string
+	// CodeValuePrefix is matched as a case sensitive prefix.
+	CodeValuePrefix string
+	// ExpirationBefore and ExpirationAfter are exclusive bounds.
+	ExpirationBefore *Date
+	ExpirationAfter  *Date
+
+	// SortBy is one of the ProductSort fields, by id when empty.
+	SortBy     string
+	Descending bool
+	Offset     int
+	Limit      int
+}
+
+// ProductPage is a page of the products matching a search.
+type ProductPage struct {
+	Products []Product
+	// Total is the number of products matching the search, in all pages.
+	Total int
+}
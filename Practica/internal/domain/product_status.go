@@ -0,0 +1,39 @@
+package domain
+
+// States of the lifecycle of a product.
+const (
+	ProductDraft     = "draft"
+	ProductPublished = "published"
+	ProductArchived  = "archived"
+)
+
+// productTransitions are the states a product can move to from each state.
+// Archived is final.
+var productTransitions = map[string][]string{
+	ProductDraft:     {ProductPublished, ProductArchived},
+	ProductPublished: {ProductDraft, ProductArchived},
+}
+
+// CanTransition reports whether the product can move from its status to status to.
+func (p Product) CanTransition(to string) bool {
+	for _, s := range productTransitions[p.Status] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// SetStatus sets the status of the product, keeping IsPublished in sync.
+func (p *Product) SetStatus(status string) {
+	p.Status = status
+	p.IsPublished = status == ProductPublished
+}
+
+// LegacyStatus is the status of a product stored before the lifecycle, from its is_published flag.
+func LegacyStatus(isPublished bool) string {
+	if isPublished {
+		return ProductPublished
+	}
+	return ProductDraft
+}
@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestProduct_Validate(t *testing.T) {
+	valid := Product{Name: "Milk", Quantity: 10, CodeValue: "MLK1", Expiration: NewDate(time.Now()), Price: 2.5}
+
+	tests := []struct {
+		name   string
+		change func(p *Product)
+		want   error
+	}{
+		{name: "valid", change: func(p *Product) {}},
+		{name: "sold out", change: func(p *Product) { p.Quantity = 0 }},
+		{name: "missing name", change: func(p *Product) { p.Name = "" }, want: ErrProductFieldsMissing},
+		{name: "missing price", change: func(p *Product) { p.Price = 0 }, want: ErrProductFieldsMissing},
+		{name: "negative quantity", change: func(p *Product) { p.Quantity = -1 }, want: ErrProductQuantityInvalid},
+		{name: "negative price", change: func(p *Product) { p.Price = -2.5 }, want: ErrProductPriceInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := valid
+			tt.change(&p)
+
+			if err := p.Validate(); !errors.Is(err, tt.want) {
+				t.Fatalf("Validate() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestProductPatch_Validate(t *testing.T) {
+	name, empty := "Milk", ""
+	quantity, zeroQuantity, negativeQuantity := 3, 0, -3
+	price, zeroPrice, negativePrice := 2.5, 0.0, -2.5
+
+	tests := []struct {
+		name  string
+		patch ProductPatch
+		want  error
+	}{
+		{name: "name", patch: ProductPatch{Name: &name}},
+		{name: "quantity and price", patch: ProductPatch{Quantity: &quantity, Price: &price}},
+		{name: "sold out", patch: ProductPatch{Quantity: &zeroQuantity}},
+		{name: "no fields", patch: ProductPatch{}, want: ErrProductPatchEmpty},
+		{name: "empty name", patch: ProductPatch{Name: &empty}, want: ErrProductFieldsEmpty},
+		{name: "zero price", patch: ProductPatch{Price: &zeroPrice}, want: ErrProductFieldsEmpty},
+		{name: "negative quantity", patch: ProductPatch{Quantity: &negativeQuantity}, want: ErrProductQuantityInvalid},
+		{name: "negative price", patch: ProductPatch{Name: &name, Price: &negativePrice}, want: ErrProductPriceInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.patch.Validate(); !errors.Is(err, tt.want) {
+				t.Fatalf("Validate() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
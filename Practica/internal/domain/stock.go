@@ -0,0 +1,23 @@
+package domain
+
+import "time"
+
+// Kinds of stock movements.
+const (
+	StockIn     = "in"
+	StockOut    = "out"
+	StockAdjust = "adjust"
+)
+
+// StockMovement is a change of the quantity of a product.
+type StockMovement struct {
+	ID        int    `json:"id"`
+	ProductID int    `json:"product_id"`
+	Kind      string `json:"kind"`
+	// Delta is the change of the quantity, negative for outflows.
+	Delta int `json:"delta"`
+	// Quantity is the quantity of the product after the movement.
+	Quantity int       `json:"quantity"`
+	Reason   string    `json:"reason"`
+	Time     time.Time `json:"time"`
+}
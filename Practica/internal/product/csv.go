@@ -0,0 +1,112 @@
+package product
+
+import (
+	"Practica/internal/domain"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// CSVHeader are the columns of the products in csv, as exported.
+// The import reads the columns by name, in any order, and ignores id, is_published and status.
+var CSVHeader = []string{"id", "name", "quantity", "code_value", "is_published", "status", "expiration", "price"}
+
+// csvRequired are the columns an import must have.
+var csvRequired = []string{"name", "quantity", "code_value", "expiration", "price"}
+
+// ImportRow is a product read from an import, Err is set when the row can't be read.
+type ImportRow struct {
+	Product domain.Product
+	Err     error
+}
+
+// ReadCSV reads the rows of a csv with a header.
+// It fails only when the header can't be read, the errors of each row are kept in the row.
+func ReadCSV(r io.Reader) ([]ImportRow, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("could not read csv header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range csvRequired {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("csv header is missing column %s", name)
+		}
+	}
+
+	rows := make([]ImportRow, 0)
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, fmt.Errorf("could not read csv: %w", err)
+			}
+			rows = append(rows, ImportRow{Err: fmt.Errorf("invalid csv row: %w", parseErr.Err)})
+			continue
+		}
+		rows = append(rows, readCSVRecord(record, columns))
+	}
+	return rows, nil
+}
+
+// readCSVRecord reads a product from a record.
+func readCSVRecord(record []string, columns map[string]int) (row ImportRow) {
+	field := func(name string) string {
+		if i := columns[name]; i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	// the code_value is kept even if the row is invalid, to report it
+	row.Product = domain.Product{Name: field("name"), CodeValue: field("code_value")}
+	p := &row.Product
+	var err error
+	if raw := field("quantity"); raw != "" {
+		if p.Quantity, err = strconv.Atoi(raw); err != nil {
+			row.Err = fmt.Errorf("invalid quantity %q", raw)
+			return
+		}
+	}
+	if raw := field("price"); raw != "" {
+		if p.Price, err = strconv.ParseFloat(raw, 64); err != nil {
+			row.Err = fmt.Errorf("invalid price %q", raw)
+			return
+		}
+	}
+	if raw := field("expiration"); raw != "" {
+		if p.Expiration, err = domain.ParseDate(raw); err != nil {
+			row.Err = fmt.Errorf("invalid date format for expiration")
+			return
+		}
+	}
+	return
+}
+
+// CSVRecord returns the fields of the product in the order of CSVHeader.
+func CSVRecord(p domain.Product) []string {
+	return []string{
+		strconv.Itoa(p.ID),
+		p.Name,
+		strconv.Itoa(p.Quantity),
+		p.CodeValue,
+		strconv.FormatBool(p.IsPublished),
+		p.Status,
+		p.Expiration.String(),
+		strconv.FormatFloat(p.Price, 'f', -1, 64),
+	}
+}
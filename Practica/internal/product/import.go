@@ -0,0 +1,69 @@
+package product
+
+import "Practica/internal/domain"
+
+// Actions of the rows of an import.
+const (
+	ImportCreated = "created"
+	ImportUpdated = "updated"
+	ImportFailed  = "failed"
+)
+
+// ImportResult is the outcome of a row of an import.
+type ImportResult struct {
+	// Row is the position of the row in the import, from 1.
+	Row       int    `json:"row"`
+	CodeValue string `json:"code_value,omitempty"`
+	Action    string `json:"action"`
+	ID        int    `json:"id,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// ImportProducts validates each row like AddProduct and UpdateProduct and upserts it by code_value:
+// an existing product is replaced keeping its id and status, a new one is created as a draft.
+// A change of the quantity of an existing product is recorded as an adjust movement.
+// Invalid rows are reported and skipped, the error is returned only when the storage fails.
+func (s *ProductService) ImportProducts(rows []ImportRow) ([]ImportResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	byCodeValue := make(map[string]domain.Product)
+	for _, p := range s.repository.GetAllProducts() {
+		byCodeValue[p.CodeValue] = p
+	}
+
+	results := make([]ImportResult, len(rows))
+	for i, row := range rows {
+		p := row.Product
+		results[i] = ImportResult{Row: i + 1, CodeValue: p.CodeValue}
+		err := row.Err
+		if err == nil {
+			err = s.ValidateProductFields(&p)
+		}
+		if err != nil {
+			results[i].Action, results[i].Error = ImportFailed, err.Error()
+			continue
+		}
+
+		if existing, ok := byCodeValue[p.CodeValue]; ok {
+			p.ID = existing.ID
+			p.SetStatus(existing.Status)
+			if err := s.repository.UpdateProduct(p); err != nil {
+				return results[:i], err
+			}
+			if err := s.adjustStock(existing, p, "product imported"); err != nil {
+				return results[:i], err
+			}
+			results[i].Action = ImportUpdated
+		} else {
+			p.SetStatus(domain.ProductDraft)
+			if err := s.repository.AddProduct(&p); err != nil {
+				return results[:i], err
+			}
+			results[i].Action = ImportCreated
+		}
+		results[i].ID = p.ID
+		byCodeValue[p.CodeValue] = p
+	}
+	return results, nil
+}
@@ -0,0 +1,55 @@
+package product
+
+import (
+	"Practica/internal/domain"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrInvalidTransition = errors.New("invalid status transition")
+	ErrProductExpired    = errors.New("product expired")
+)
+
+// transition moves the product of id to status to, once check accepts the product.
+func (s *ProductService) transition(id int, to string, check func(domain.Product) error) (p domain.Product, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if p, err = s.repository.GetById(id); err != nil {
+		return
+	}
+	if !p.CanTransition(to) {
+		err = fmt.Errorf("%w: can't move a product from %s to %s", ErrInvalidTransition, p.Status, to)
+		return
+	}
+	if check != nil {
+		if err = check(p); err != nil {
+			return
+		}
+	}
+	p.SetStatus(to)
+	err = s.repository.UpdateProduct(p)
+	return
+}
+
+// Publish makes a draft product public, it can't be expired before today.
+func (s *ProductService) Publish(id int, today domain.Date) (domain.Product, error) {
+	return s.transition(id, domain.ProductPublished, func(p domain.Product) error {
+		if p.Expired(today) {
+			return fmt.Errorf("%w: can't publish a product that expired on %s", ErrProductExpired, p.Expiration)
+		}
+		return nil
+	})
+}
+
+// Archive retires a draft or published product, archived products can't change their status.
+func (s *ProductService) Archive(id int) (domain.Product, error) {
+	return s.transition(id, domain.ProductArchived, nil)
+}
+
+// GetPublished returns the page of the published products matching q.
+func (s *ProductService) GetPublished(q domain.ProductQuery) (domain.ProductPage, error) {
+	q.Status = domain.ProductPublished
+	return s.Search(q)
+}
@@ -0,0 +1,96 @@
+package product
+
+import (
+	"Practica/internal/domain"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestProductService_Transitions(t *testing.T) {
+	publish := func(s *ProductService, id int) (domain.Product, error) { return s.Publish(id, day(10)) }
+	archive := func(s *ProductService, id int) (domain.Product, error) { return s.Archive(id) }
+
+	tests := []struct {
+		name   string
+		status string
+		// expiration is the day of January 2030 the product expires, today is the 10th
+		expiration int
+		action     func(s *ProductService, id int) (domain.Product, error)
+		want       string
+		wantErr    error
+	}{
+		{name: "publish a draft", status: domain.ProductDraft, expiration: 20, action: publish, want: domain.ProductPublished},
+		{name: "publish a draft expiring today", status: domain.ProductDraft, expiration: 10, action: publish, want: domain.ProductPublished},
+		{name: "publish an expired draft", status: domain.ProductDraft, expiration: 9, action: publish, wantErr: ErrProductExpired},
+		{name: "publish a published product", status: domain.ProductPublished, expiration: 20, action: publish, wantErr: ErrInvalidTransition},
+		{name: "publish an archived product", status: domain.ProductArchived, expiration: 20, action: publish, wantErr: ErrInvalidTransition},
+		{name: "archive a draft", status: domain.ProductDraft, expiration: 20, action: archive, want: domain.ProductArchived},
+		{name: "archive a published product", status: domain.ProductPublished, expiration: 20, action: archive, want: domain.ProductArchived},
+		{name: "archive an expired product", status: domain.ProductPublished, expiration: 9, action: archive, want: domain.ProductArchived},
+		{name: "archive an archived product", status: domain.ProductArchived, expiration: 20, action: archive, wantErr: ErrInvalidTransition},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewProductService(NewProductMemory([]domain.Product{
+				searchProduct(1, "Milk", "MLK1", tt.status, 1, 2.5, tt.expiration),
+			}), NewStockMovementMemory())
+
+			got, err := tt.action(s, 1)
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			want := tt.want
+			if tt.wantErr != nil {
+				// a rejected transition leaves the product as it was
+				want = tt.status
+			} else if got.Status != want || got.IsPublished != (want == domain.ProductPublished) {
+				t.Fatalf("product = %s (published %t), want %s", got.Status, got.IsPublished, want)
+			}
+			if p, _ := s.GetById(1); p.Status != want || p.IsPublished != (want == domain.ProductPublished) {
+				t.Fatalf("stored product = %s (published %t), want %s", p.Status, p.IsPublished, want)
+			}
+		})
+	}
+
+	t.Run("missing product", func(t *testing.T) {
+		s := NewProductService(NewProductMemory(nil), NewStockMovementMemory())
+
+		if _, err := s.Publish(1, day(10)); !errors.Is(err, ErrProductNotFound) {
+			t.Fatalf("Publish() error = %v, want %v", err, ErrProductNotFound)
+		}
+	})
+}
+
+func TestProductService_GetPublished(t *testing.T) {
+	s := NewProductService(NewProductMemory([]domain.Product{
+		searchProduct(1, "Milk", "MLK1", domain.ProductPublished, 1, 2.5, 20),
+		searchProduct(2, "Oil", "OIL1", domain.ProductDraft, 1, 10, 20),
+		searchProduct(3, "Olive oil", "OIL2", domain.ProductArchived, 1, 10, 20),
+		searchProduct(4, "Bread", "BRD1", domain.ProductPublished, 1, 1.2, 20),
+	}), NewStockMovementMemory())
+
+	tests := []struct {
+		name string
+		q    domain.ProductQuery
+		want []int
+	}{
+		{name: "drafts and archived products are filtered out", want: []int{1, 4}},
+		{name: "the status of the query is ignored", q: domain.ProductQuery{Status: domain.ProductDraft}, want: []int{1, 4}},
+		{name: "with the filters of a search", q: domain.ProductQuery{Name: "oil"}, want: []int{}},
+		{name: "sorted", q: domain.ProductQuery{SortBy: domain.ProductSortPrice}, want: []int{4, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := s.GetPublished(tt.q)
+			if err != nil {
+				t.Fatalf("GetPublished() error = %v", err)
+			}
+
+			if got := productIDs(page.Products); !slices.Equal(got, tt.want) {
+				t.Fatalf("GetPublished() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
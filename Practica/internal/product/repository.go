@@ -2,49 +2,23 @@ package product
 
 import (
 	"Practica/internal/domain"
-	"fmt"
+	"errors"
 )
 
-type ProductRepository struct {
-	productsDB []domain.Product
-}
-
-func NewProductRepository(prods []domain.Product) ProductRepository {
-	return ProductRepository{prods}
-}
-
-func (r ProductRepository) GetAllProducts() []domain.Product {
-	return r.productsDB
-}
-
-func (r ProductRepository) GetById(id int) domain.Product {
-	return r.productsDB[id-1]
-}
-
-func (r *ProductRepository) AddProduct(newProduct domain.Product) {
-	r.productsDB = append(r.productsDB, newProduct)
-}
-
-func (r *ProductRepository) UpdateProduct(updatedProduct domain.Product) error {
-	for i, existingProduct := range r.productsDB {
-		if existingProduct.ID == updatedProduct.ID {
-			r.productsDB[i] = updatedProduct
-			return nil
-		}
-	}
-	return fmt.Errorf("product not found")
-}
-
-// product/repository.go
-
-func (r *ProductRepository) DeleteProduct(id int) {
-	updatedProducts := make([]domain.Product, 0)
-
-	for _, existingProduct := range r.productsDB {
-		if existingProduct.ID != id {
-			updatedProducts = append(updatedProducts, existingProduct)
-		}
-	}
-
-	r.productsDB = updatedProducts
+var ErrProductNotFound = errors.New("product not found")
+
+// ProductRepository is the storage of the products.
+type ProductRepository interface {
+	// GetAllProducts returns the products ordered by id
+	GetAllProducts() []domain.Product
+	// GetById returns ErrProductNotFound when there is no product with the id
+	GetById(id int) (domain.Product, error)
+	// AddProduct assigns the next id of the sequence to the product and stores it
+	AddProduct(newProduct *domain.Product) error
+	// Search returns the page of the products matching q, sorted as requested
+	Search(q domain.ProductQuery) (domain.ProductPage, error)
+	// UpdateProduct returns ErrProductNotFound when there is no product with the id
+	UpdateProduct(updatedProduct domain.Product) error
+	// DeleteProduct returns ErrProductNotFound when there is no product with the id
+	DeleteProduct(id int) error
 }
@@ -0,0 +1,100 @@
+package product
+
+import (
+	"Practica/internal/domain"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+)
+
+// ProductFile is a ProductRepository that keeps the products in memory
+// and writes all of them back to a json file after every change.
+// It is safe for concurrent use.
+type ProductFile struct {
+	// mu serializes the changes, so the file is written in the same order.
+	mu     sync.Mutex
+	memory *ProductMemory
+	path   string
+}
+
+func NewProductFile(path string, prods []domain.Product) *ProductFile {
+	return &ProductFile{memory: NewProductMemory(prods), path: path}
+}
+
+func (r *ProductFile) GetAllProducts() []domain.Product {
+	return r.memory.GetAllProducts()
+}
+
+func (r *ProductFile) GetById(id int) (domain.Product, error) {
+	return r.memory.GetById(id)
+}
+
+func (r *ProductFile) Search(q domain.ProductQuery) (domain.ProductPage, error) {
+	return r.memory.Search(q)
+}
+
+// AddProduct writes the products with the new one to the file and then adds it to memory,
+// so a failed write leaves the repository unchanged.
+func (r *ProductFile) AddProduct(newProduct *domain.Product) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p := *newProduct
+	p.ID = r.memory.nextID()
+	if err := r.save(append(r.memory.GetAllProducts(), p)); err != nil {
+		return err
+	}
+	return r.memory.AddProduct(newProduct)
+}
+
+// UpdateProduct writes the products with the change to the file and then updates memory.
+func (r *ProductFile) UpdateProduct(updatedProduct domain.Product) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	products := r.memory.GetAllProducts()
+	i := slices.IndexFunc(products, func(p domain.Product) bool { return p.ID == updatedProduct.ID })
+	if i < 0 {
+		return ErrProductNotFound
+	}
+	products[i] = updatedProduct
+	if err := r.save(products); err != nil {
+		return err
+	}
+	return r.memory.UpdateProduct(updatedProduct)
+}
+
+// DeleteProduct writes the products without the deleted one to the file and then deletes it from memory.
+func (r *ProductFile) DeleteProduct(id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	products := r.memory.GetAllProducts()
+	i := slices.IndexFunc(products, func(p domain.Product) bool { return p.ID == id })
+	if i < 0 {
+		return ErrProductNotFound
+	}
+	if err := r.save(slices.Delete(products, i, i+1)); err != nil {
+		return err
+	}
+	return r.memory.DeleteProduct(id)
+}
+
+// save writes products to a temporary file and renames it over the json file,
+// so the file is never left half written.
+func (r *ProductFile) save(products []domain.Product) error {
+	data, err := json.MarshalIndent(products, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not encode products: %w", err)
+	}
+	tmp := r.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("could not write products: %w", err)
+	}
+	if err := os.Rename(tmp, r.path); err != nil {
+		return fmt.Errorf("could not write products: %w", err)
+	}
+	return nil
+}
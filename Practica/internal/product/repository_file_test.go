@@ -0,0 +1,108 @@
+package product
+
+import (
+	"Practica/internal/domain"
+	"Practica/pkg"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestProductFile_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "products.json")
+	r := NewProductFile(path, []domain.Product{newStockProduct(1, 5), newStockProduct(2, 3)})
+
+	added := newStockProduct(0, 9)
+	added.CodeValue = "MLK3"
+	if err := r.AddProduct(&added); err != nil {
+		t.Fatalf("AddProduct() error = %v", err)
+	}
+	updated := newStockProduct(1, 4)
+	updated.Name = "Whole milk"
+	if err := r.UpdateProduct(updated); err != nil {
+		t.Fatalf("UpdateProduct() error = %v", err)
+	}
+	if err := r.DeleteProduct(2); err != nil {
+		t.Fatalf("DeleteProduct() error = %v", err)
+	}
+
+	got, err := pkg.LoadProducts(path)
+	if err != nil {
+		t.Fatalf("LoadProducts() error = %v", err)
+	}
+	if want := []domain.Product{updated, added}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("file = %+v, want %+v", got, want)
+	}
+	if want := r.GetAllProducts(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("file = %+v, want the products in memory %+v", got, want)
+	}
+}
+
+func TestProductFile_SaveFailureLeavesMemoryUnchanged(t *testing.T) {
+	// the directory of the file does not exist, so every write fails
+	path := filepath.Join(t.TempDir(), "missing", "products.json")
+	prods := []domain.Product{newStockProduct(1, 5), newStockProduct(2, 3)}
+
+	tests := []struct {
+		name   string
+		change func(r *ProductFile) error
+	}{
+		{name: "add", change: func(r *ProductFile) error {
+			p := newStockProduct(0, 9)
+			return r.AddProduct(&p)
+		}},
+		{name: "update", change: func(r *ProductFile) error {
+			return r.UpdateProduct(newStockProduct(1, 1))
+		}},
+		{name: "delete", change: func(r *ProductFile) error {
+			return r.DeleteProduct(2)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewProductFile(path, prods)
+
+			if err := tt.change(r); err == nil {
+				t.Fatalf("change error = nil, want the write error")
+			}
+
+			if got := r.GetAllProducts(); !reflect.DeepEqual(got, prods) {
+				t.Fatalf("products = %+v, want %+v", got, prods)
+			}
+		})
+	}
+
+	t.Run("add after a failure gets the next id", func(t *testing.T) {
+		r := NewProductFile(path, prods)
+		p := newStockProduct(0, 9)
+		_ = r.AddProduct(&p)
+		r.path = filepath.Join(t.TempDir(), "products.json")
+
+		if err := r.AddProduct(&p); err != nil {
+			t.Fatalf("AddProduct() error = %v", err)
+		}
+		if p.ID != 3 {
+			t.Fatalf("id = %d, want 3", p.ID)
+		}
+	})
+}
+
+func TestProductService_MoveStockSaveFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing", "products.json")
+	s := NewProductService(NewProductFile(path, []domain.Product{newStockProduct(1, 5)}), NewStockMovementMemory())
+
+	if _, err := s.MoveStock(1, domain.StockOut, 2, "sold"); err == nil {
+		t.Fatalf("MoveStock() error = nil, want the write error")
+	}
+
+	p, err := s.GetById(1)
+	if err != nil {
+		t.Fatalf("GetById() error = %v", err)
+	}
+	if p.Quantity != 5 {
+		t.Fatalf("quantity = %d, want 5", p.Quantity)
+	}
+	if m, err := s.Movements(1); err != nil || len(m) != 0 {
+		t.Fatalf("Movements() = %+v, %v, want none", m, err)
+	}
+}
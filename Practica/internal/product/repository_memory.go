@@ -0,0 +1,102 @@
+package product
+
+import (
+	"Practica/internal/domain"
+	"sort"
+	"sync"
+)
+
+// ProductMemory is a ProductRepository that keeps the products in a map by id.
+// It is safe for concurrent use.
+type ProductMemory struct {
+	mu         sync.RWMutex
+	productsDB map[int]domain.Product
+	// lastID is the last id assigned, ids are never reused after a delete.
+	lastID int
+}
+
+// NewProductMemory returns a repository with prods, the id sequence starts after the max id.
+func NewProductMemory(prods []domain.Product) *ProductMemory {
+	r := &ProductMemory{productsDB: make(map[int]domain.Product, len(prods))}
+	for _, p := range prods {
+		r.productsDB[p.ID] = p
+		if p.ID > r.lastID {
+			r.lastID = p.ID
+		}
+	}
+	return r
+}
+
+func (r *ProductMemory) GetAllProducts() []domain.Product {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	products := make([]domain.Product, 0, len(r.productsDB))
+	for _, p := range r.productsDB {
+		products = append(products, p)
+	}
+	sort.Slice(products, func(i, j int) bool { return products[i].ID < products[j].ID })
+	return products
+}
+
+func (r *ProductMemory) GetById(id int) (domain.Product, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	p, ok := r.productsDB[id]
+	if !ok {
+		return domain.Product{}, ErrProductNotFound
+	}
+	return p, nil
+}
+
+func (r *ProductMemory) Search(q domain.ProductQuery) (domain.ProductPage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	products := make([]domain.Product, 0, len(r.productsDB))
+	for _, p := range r.productsDB {
+		products = append(products, p)
+	}
+	return search(products, q), nil
+}
+
+// nextID returns the id that AddProduct assigns to the next product.
+func (r *ProductMemory) nextID() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.lastID + 1
+}
+
+func (r *ProductMemory) AddProduct(newProduct *domain.Product) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastID++
+	newProduct.ID = r.lastID
+	r.productsDB[newProduct.ID] = *newProduct
+	return nil
+}
+
+func (r *ProductMemory) UpdateProduct(updatedProduct domain.Product) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.productsDB[updatedProduct.ID]; !ok {
+		return ErrProductNotFound
+	}
+	r.productsDB[updatedProduct.ID] = updatedProduct
+	return nil
+}
+
+func (r *ProductMemory) DeleteProduct(id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.productsDB[id]; !ok {
+		return ErrProductNotFound
+	}
+	delete(r.productsDB, id)
+	return nil
+}
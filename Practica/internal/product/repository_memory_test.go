@@ -0,0 +1,56 @@
+package product
+
+import (
+	"Practica/internal/domain"
+	"errors"
+	"testing"
+)
+
+func TestProductMemory_IDSequence(t *testing.T) {
+	// seeded from the max id, whatever the order of the products
+	r := NewProductMemory([]domain.Product{newStockProduct(7, 1), newStockProduct(3, 1)})
+	add := func() int {
+		t.Helper()
+		p := newStockProduct(0, 1)
+		if err := r.AddProduct(&p); err != nil {
+			t.Fatalf("AddProduct() error = %v", err)
+		}
+		return p.ID
+	}
+
+	if id := add(); id != 8 {
+		t.Fatalf("first id = %d, want 8", id)
+	}
+
+	// the id of a deleted product is not reused, even if it was the last one
+	if err := r.DeleteProduct(8); err != nil {
+		t.Fatalf("DeleteProduct() error = %v", err)
+	}
+	if err := r.DeleteProduct(7); err != nil {
+		t.Fatalf("DeleteProduct() error = %v", err)
+	}
+	if id := add(); id != 9 {
+		t.Fatalf("id after delete = %d, want 9", id)
+	}
+	if _, err := r.GetById(8); !errors.Is(err, ErrProductNotFound) {
+		t.Fatalf("GetById(deleted) error = %v, want %v", err, ErrProductNotFound)
+	}
+}
+
+func TestProductMemory_NotFound(t *testing.T) {
+	r := NewProductMemory([]domain.Product{newStockProduct(1, 1)})
+
+	if _, err := r.GetById(2); !errors.Is(err, ErrProductNotFound) {
+		t.Fatalf("GetById() error = %v, want %v", err, ErrProductNotFound)
+	}
+	if err := r.UpdateProduct(newStockProduct(2, 1)); !errors.Is(err, ErrProductNotFound) {
+		t.Fatalf("UpdateProduct() error = %v, want %v", err, ErrProductNotFound)
+	}
+	if err := r.DeleteProduct(2); !errors.Is(err, ErrProductNotFound) {
+		t.Fatalf("DeleteProduct() error = %v, want %v", err, ErrProductNotFound)
+	}
+	// the missing product was not created
+	if got := r.GetAllProducts(); len(got) != 1 || got[0].ID != 1 {
+		t.Fatalf("GetAllProducts() = %+v, want only product 1", got)
+	}
+}
@@ -0,0 +1,40 @@
+package product
+
+import (
+	"Practica/internal/domain"
+	"context"
+	"log"
+	"time"
+)
+
+// ExpirationScheduler unpublishes the expired products periodically.
+type ExpirationScheduler struct {
+	service  *ProductService
+	interval time.Duration
+}
+
+func NewExpirationScheduler(serv *ProductService, interval time.Duration) *ExpirationScheduler {
+	return &ExpirationScheduler{service: serv, interval: interval}
+}
+
+// Run unpublishes the expired products right away and then every interval, until ctx is done.
+func (s *ExpirationScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		n, err := s.service.UnpublishExpired(domain.NewDate(time.Now()))
+		switch {
+		case err != nil:
+			log.Printf("unpublish expired products: %v", err)
+		case n > 0:
+			log.Printf("unpublished %d expired products", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package product
+
+import (
+	"Practica/internal/domain"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestExpirationScheduler_Run(t *testing.T) {
+	expired := newStockProduct(1, 1)
+	expired.SetStatus(domain.ProductPublished)
+	expired.Expiration = domain.NewDate(time.Now().AddDate(0, 0, -1))
+	s := NewProductService(NewProductMemory([]domain.Product{expired}), NewStockMovementMemory())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		NewExpirationScheduler(s, time.Millisecond).Run(ctx)
+		close(done)
+	}()
+
+	// unpublishes the expired product
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		p, err := s.GetById(1)
+		if err != nil {
+			t.Fatalf("GetById() error = %v", err)
+		}
+		if p.Status == domain.ProductDraft && !p.IsPublished {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("product is %s, want it unpublished", p.Status)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// and stops when ctx is cancelled
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run() did not return after ctx was cancelled")
+	}
+}
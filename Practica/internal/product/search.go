@@ -0,0 +1,89 @@
+package product
+
+import (
+	"Practica/internal/domain"
+	"sort"
+	"strings"
+)
+
+// matches reports whether p matches the filters of q.
+func matches(p domain.Product, q domain.ProductQuery) bool {
+	switch {
+	case q.PriceGt != nil && !(p.Price > *q.PriceGt),
+		q.PriceGte != nil && !(p.Price >= *q.PriceGte),
+		q.PriceLte != nil && !(p.Price <= *q.PriceLte),
+		q.QuantityMin != nil && p.Quantity < *q.QuantityMin,
+		q.IsPublished != nil && p.IsPublished != *q.IsPublished,
+		q.Status != "" && p.Status != q.Status,
+		q.Name != "" && !strings.Contains(strings.ToLower(p.Name), strings.ToLower(q.Name)),
+		q.CodeValuePrefix != "" && !strings.HasPrefix(p.CodeValue, q.CodeValuePrefix):
+		return false
+	}
+	switch {
+	case q.ExpirationBefore != nil && !p.Expiration.Before(q.ExpirationBefore.Time),
+		q.ExpirationAfter != nil && !p.Expiration.After(q.ExpirationAfter.Time):
+		return false
+	}
+	return true
+}
+
+// less reports whether a goes before b when sorting by field, ties are broken by id.
+func less(a, b domain.Product, field string) bool {
+	switch field {
+	case domain.ProductSortName:
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+	case domain.ProductSortQuantity:
+		if a.Quantity != b.Quantity {
+			return a.Quantity < b.Quantity
+		}
+	case domain.ProductSortCodeValue:
+		if a.CodeValue != b.CodeValue {
+			return a.CodeValue < b.CodeValue
+		}
+	case domain.ProductSortIsPublished:
+		if a.IsPublished != b.IsPublished {
+			return !a.IsPublished
+		}
+	case domain.ProductSortStatus:
+		if a.Status != b.Status {
+			return a.Status < b.Status
+		}
+	case domain.ProductSortExpiration:
+		if !a.Expiration.Equal(b.Expiration.Time) {
+			return a.Expiration.Before(b.Expiration.Time)
+		}
+	case domain.ProductSortPrice:
+		if a.Price != b.Price {
+			return a.Price < b.Price
+		}
+	}
+	return a.ID < b.ID
+}
+
+// search filters, sorts and paginates products as requested by q.
+func search(products []domain.Product, q domain.ProductQuery) domain.ProductPage {
+	result := make([]domain.Product, 0)
+	for _, p := range products {
+		if matches(p, q) {
+			result = append(result, p)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if q.Descending {
+			return less(result[j], result[i], q.SortBy)
+		}
+		return less(result[i], result[j], q.SortBy)
+	})
+
+	page := domain.ProductPage{Total: len(result), Products: make([]domain.Product, 0)}
+	if q.Offset < len(result) {
+		result = result[q.Offset:]
+		if q.Limit > 0 && q.Limit < len(result) {
+			result = result[:q.Limit]
+		}
+		page.Products = result
+	}
+	return page
+}
@@ -0,0 +1,141 @@
+package product
+
+import (
+	"Practica/internal/domain"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+// searchProduct returns a product with the fields searches filter and sort by, expiring on day of January 2030.
+func searchProduct(id int, name, codeValue, status string, quantity int, price float64, day int) domain.Product {
+	p := domain.Product{ID: id, Name: name, Quantity: quantity, CodeValue: codeValue, Price: price,
+		Expiration: domain.NewDate(time.Date(2030, time.January, day, 0, 0, 0, 0, time.UTC))}
+	p.SetStatus(status)
+	return p
+}
+
+// productIDs returns the ids of the products, in order.
+func productIDs(products []domain.Product) []int {
+	ids := make([]int, len(products))
+	for i := range products {
+		ids[i] = products[i].ID
+	}
+	return ids
+}
+
+func TestProductService_Search(t *testing.T) {
+	s := NewProductService(NewProductMemory([]domain.Product{
+		searchProduct(1, "Milk", "MLK1", domain.ProductPublished, 10, 2.5, 10),
+		searchProduct(2, "Whole milk", "MLK2", domain.ProductDraft, 0, 3, 5),
+		searchProduct(3, "Oil", "OIL1", domain.ProductPublished, 5, 10, 20),
+		searchProduct(4, "Olive oil", "OIL2", domain.ProductArchived, 20, 10, 1),
+		searchProduct(5, "Bread", "BRD1", domain.ProductPublished, 5, 1.2, 15),
+	}), NewStockMovementMemory())
+	float := func(v float64) *float64 { return &v }
+	integer := func(v int) *int { return &v }
+	boolean := func(v bool) *bool { return &v }
+	date := func(day int) *domain.Date {
+		d := domain.NewDate(time.Date(2030, time.January, day, 0, 0, 0, 0, time.UTC))
+		return &d
+	}
+
+	tests := []struct {
+		name      string
+		q         domain.ProductQuery
+		want      []int
+		wantTotal int
+	}{
+		{name: "no filter", want: []int{1, 2, 3, 4, 5}, wantTotal: 5},
+		// filters
+		{name: "price greater than", q: domain.ProductQuery{PriceGt: float(3)}, want: []int{3, 4}, wantTotal: 2},
+		{name: "price bounds are inclusive", q: domain.ProductQuery{PriceGte: float(2.5), PriceLte: float(3)}, want: []int{1, 2}, wantTotal: 2},
+		{name: "equal price bounds", q: domain.ProductQuery{PriceGte: float(10), PriceLte: float(10)}, want: []int{3, 4}, wantTotal: 2},
+		{name: "quantity min", q: domain.ProductQuery{QuantityMin: integer(5)}, want: []int{1, 3, 4, 5}, wantTotal: 4},
+		{name: "not published", q: domain.ProductQuery{IsPublished: boolean(false)}, want: []int{2, 4}, wantTotal: 2},
+		{name: "status", q: domain.ProductQuery{Status: domain.ProductArchived}, want: []int{4}, wantTotal: 1},
+		{name: "name is a case insensitive substring", q: domain.ProductQuery{Name: "MILK"}, want: []int{1, 2}, wantTotal: 2},
+		{name: "code value is a case sensitive prefix", q: domain.ProductQuery{CodeValuePrefix: "OIL"}, want: []int{3, 4}, wantTotal: 2},
+		{name: "lowercase code value prefix", q: domain.ProductQuery{CodeValuePrefix: "oil"}, want: []int{}, wantTotal: 0},
+		{name: "expiration bounds are exclusive", q: domain.ProductQuery{ExpirationAfter: date(5), ExpirationBefore: date(15)}, want: []int{1}, wantTotal: 1},
+		// combined filters
+		{name: "name and price", q: domain.ProductQuery{Name: "oil", PriceGte: float(10)}, want: []int{3, 4}, wantTotal: 2},
+		{name: "published and quantity", q: domain.ProductQuery{IsPublished: boolean(true), QuantityMin: integer(6)}, want: []int{1}, wantTotal: 1},
+		{name: "status, code value and expiration", q: domain.ProductQuery{Status: domain.ProductPublished, CodeValuePrefix: "OIL", ExpirationBefore: date(20)}, want: []int{}, wantTotal: 0},
+		{name: "every filter", q: domain.ProductQuery{PriceGt: float(1), PriceGte: float(2), PriceLte: float(10), QuantityMin: integer(1),
+			IsPublished: boolean(true), Status: domain.ProductPublished, Name: "i", CodeValuePrefix: "M",
+			ExpirationAfter: date(1), ExpirationBefore: date(31)}, want: []int{1}, wantTotal: 1},
+		// sorting
+		{name: "sort by name", q: domain.ProductQuery{SortBy: domain.ProductSortName}, want: []int{5, 1, 3, 4, 2}, wantTotal: 5},
+		{name: "sort by price breaks ties by id", q: domain.ProductQuery{SortBy: domain.ProductSortPrice}, want: []int{5, 1, 2, 3, 4}, wantTotal: 5},
+		{name: "sort by price descending", q: domain.ProductQuery{SortBy: domain.ProductSortPrice, Descending: true}, want: []int{4, 3, 2, 1, 5}, wantTotal: 5},
+		{name: "sort by quantity", q: domain.ProductQuery{SortBy: domain.ProductSortQuantity}, want: []int{2, 3, 5, 1, 4}, wantTotal: 5},
+		{name: "sort by code value", q: domain.ProductQuery{SortBy: domain.ProductSortCodeValue}, want: []int{5, 1, 2, 3, 4}, wantTotal: 5},
+		{name: "sort by is published", q: domain.ProductQuery{SortBy: domain.ProductSortIsPublished}, want: []int{2, 4, 1, 3, 5}, wantTotal: 5},
+		{name: "sort by status", q: domain.ProductQuery{SortBy: domain.ProductSortStatus}, want: []int{4, 2, 1, 3, 5}, wantTotal: 5},
+		{name: "sort by expiration", q: domain.ProductQuery{SortBy: domain.ProductSortExpiration}, want: []int{4, 2, 1, 5, 3}, wantTotal: 5},
+		{name: "sort by id descending", q: domain.ProductQuery{SortBy: domain.ProductSortID, Descending: true}, want: []int{5, 4, 3, 2, 1}, wantTotal: 5},
+		{name: "filter and sort", q: domain.ProductQuery{IsPublished: boolean(true), SortBy: domain.ProductSortExpiration, Descending: true}, want: []int{3, 5, 1}, wantTotal: 3},
+		// pagination
+		{name: "page", q: domain.ProductQuery{SortBy: domain.ProductSortName, Offset: 1, Limit: 2}, want: []int{1, 3}, wantTotal: 5},
+		{name: "last page", q: domain.ProductQuery{Offset: 4, Limit: 2}, want: []int{5}, wantTotal: 5},
+		{name: "offset at the end", q: domain.ProductQuery{Offset: 5}, want: []int{}, wantTotal: 5},
+		{name: "offset past the end", q: domain.ProductQuery{Name: "milk", Offset: 10}, want: []int{}, wantTotal: 2},
+		{name: "limit at the maximum", q: domain.ProductQuery{Limit: MaxSearchLimit}, want: []int{1, 2, 3, 4, 5}, wantTotal: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := s.Search(tt.q)
+			if err != nil {
+				t.Fatalf("Search() error = %v", err)
+			}
+
+			if got := productIDs(page.Products); !slices.Equal(got, tt.want) || page.Total != tt.wantTotal {
+				t.Fatalf("Search() = %v of %d, want %v of %d", got, page.Total, tt.want, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestProductService_SearchDefaultLimit(t *testing.T) {
+	prods := make([]domain.Product, DefaultSearchLimit+5)
+	for i := range prods {
+		prods[i] = newStockProduct(i+1, 1)
+	}
+	s := NewProductService(NewProductMemory(prods), NewStockMovementMemory())
+
+	page, err := s.Search(domain.ProductQuery{})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+
+	if len(page.Products) != DefaultSearchLimit || page.Total != len(prods) {
+		t.Fatalf("Search() = %d products of %d, want %d of %d", len(page.Products), page.Total, DefaultSearchLimit, len(prods))
+	}
+}
+
+func TestProductService_SearchInvalid(t *testing.T) {
+	s := NewProductService(NewProductMemory(nil), NewStockMovementMemory())
+	low, high := 5.0, 2.0
+
+	tests := []struct {
+		name string
+		q    domain.ProductQuery
+	}{
+		{name: "unknown sort field", q: domain.ProductQuery{SortBy: "weight"}},
+		{name: "negative offset", q: domain.ProductQuery{Offset: -1}},
+		{name: "negative limit", q: domain.ProductQuery{Limit: -1}},
+		{name: "limit above the maximum", q: domain.ProductQuery{Limit: MaxSearchLimit + 1}},
+		{name: "priceGte greater than priceLte", q: domain.ProductQuery{PriceGte: &low, PriceLte: &high}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.Search(tt.q)
+
+			if !errors.Is(err, ErrInvalidQuery) {
+				t.Fatalf("Search() error = %v, want %v", err, ErrInvalidQuery)
+			}
+		})
+	}
+}
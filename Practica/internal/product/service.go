@@ -2,88 +2,224 @@ package product
 
 import (
 	"Practica/internal/domain"
+	"errors"
 	"fmt"
-	"time"
+	"math"
+	"sync"
+)
+
+var (
+	ErrCodeValueNotUnique = errors.New("code_value must be unique")
+	ErrInvalidQuery       = errors.New("invalid search")
+)
+
+const (
+	// DefaultSearchLimit is the page size of a search without limit.
+	DefaultSearchLimit = 20
+	// MaxSearchLimit is the maximum page size of a search.
+	MaxSearchLimit = 100
 )
 
 type ProductService struct {
+	// mu serializes the changes, so checks like the uniqueness of code_value
+	// still hold when the change is applied.
+	mu         sync.Mutex
 	repository ProductRepository
+	movements  StockMovementRepository
 }
 
-func NewProductService(repo ProductRepository) ProductService {
-	return ProductService{repo}
+func NewProductService(repo ProductRepository, movements StockMovementRepository) *ProductService {
+	return &ProductService{repository: repo, movements: movements}
 }
 
 func (s *ProductService) GetAllProducts() []domain.Product {
 	return s.repository.GetAllProducts()
 }
 
-func (s *ProductService) GetById(id int) domain.Product {
+func (s *ProductService) GetById(id int) (domain.Product, error) {
 	return s.repository.GetById(id)
 }
 
-func (s *ProductService) GetByPrice(price float64) []domain.Product {
-	products := s.repository.GetAllProducts()
+// Search returns the page of the products matching q and the total of matches.
+// The limit defaults to DefaultSearchLimit and can't exceed MaxSearchLimit.
+func (s *ProductService) Search(q domain.ProductQuery) (domain.ProductPage, error) {
+	switch q.SortBy {
+	case "", domain.ProductSortID, domain.ProductSortName, domain.ProductSortQuantity, domain.ProductSortCodeValue,
+		domain.ProductSortIsPublished, domain.ProductSortStatus, domain.ProductSortExpiration, domain.ProductSortPrice:
+	default:
+		return domain.ProductPage{}, fmt.Errorf("%w: can't sort by %q", ErrInvalidQuery, q.SortBy)
+	}
+	switch {
+	case q.Offset < 0:
+		return domain.ProductPage{}, fmt.Errorf("%w: offset can't be negative", ErrInvalidQuery)
+	case q.Limit < 0 || q.Limit > MaxSearchLimit:
+		return domain.ProductPage{}, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidQuery, MaxSearchLimit)
+	case q.PriceGte != nil && q.PriceLte != nil && *q.PriceGte > *q.PriceLte:
+		return domain.ProductPage{}, fmt.Errorf("%w: priceGte can't be greater than priceLte", ErrInvalidQuery)
+	}
+	if q.Limit == 0 {
+		q.Limit = DefaultSearchLimit
+	}
+	return s.repository.Search(q)
+}
+
+// Expiring returns the products that expire from today to days later, both included, by expiration.
+func (s *ProductService) Expiring(today domain.Date, days int) ([]domain.Product, error) {
+	if days < 0 {
+		return nil, fmt.Errorf("%w: days can't be negative", ErrInvalidQuery)
+	}
+	after, before := today.AddDays(-1), today.AddDays(days+1)
+	page, err := s.repository.Search(domain.ProductQuery{ExpirationAfter: &after, ExpirationBefore: &before, SortBy: domain.ProductSortExpiration})
+	return page.Products, err
+}
+
+// Expired returns the products that expired before today, by expiration.
+func (s *ProductService) Expired(today domain.Date) ([]domain.Product, error) {
+	page, err := s.repository.Search(domain.ProductQuery{ExpirationBefore: &today, SortBy: domain.ProductSortExpiration})
+	return page.Products, err
+}
 
-	result := make([]domain.Product, 0)
-	for _, product := range products {
-		if product.Price > price {
-			result = append(result, product)
+// ExpiredStock returns the quantity and value (quantity * price) of the products that expired before today.
+func (s *ProductService) ExpiredStock(today domain.Date) (r domain.ExpiredStockReport, err error) {
+	products, err := s.Expired(today)
+	if err != nil {
+		return
+	}
+	for _, p := range products {
+		r.Count++
+		r.Quantity += p.Quantity
+		r.Value += p.StockValue()
+	}
+	// prices have cents, drop the error of adding floats
+	r.Value = math.Round(r.Value*100) / 100
+	return
+}
+
+// UnpublishExpired unpublishes the published products that expired before today and returns how many.
+func (s *ProductService) UnpublishExpired(today domain.Date) (n int, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	page, err := s.repository.Search(domain.ProductQuery{ExpirationBefore: &today, Status: domain.ProductPublished})
+	if err != nil {
+		return
+	}
+	for _, p := range page.Products {
+		p.SetStatus(domain.ProductDraft)
+		if err = s.repository.UpdateProduct(p); err != nil {
+			return
 		}
+		n++
 	}
-	return result
+	return
 }
 
 func (s *ProductService) ValidateProductFields(product *domain.Product) error {
-	if product.Name == "" || product.Quantity == 0 || product.CodeValue == "" ||
-		product.Expiration == "" || product.Price == 0.0 {
-		return fmt.Errorf("all fields except is_published must be provided")
-	}
-	return nil
+	return product.Validate()
 }
 
 func (s *ProductService) ValidateDateFormat(date string) error {
-	_, err := time.Parse("02/01/2006", date)
+	_, err := domain.ParseDate(date)
 	if err != nil {
 		return fmt.Errorf("invalid date format for expiration")
 	}
 	return nil
 }
 
-func (s *ProductService) ValidateUniqueCodeValue(products []domain.Product, codeValue string) error {
+// ValidateUniqueCodeValue checks that no product but the one of excludeID has codeValue,
+// excludeID is 0 for new products.
+func (s *ProductService) ValidateUniqueCodeValue(products []domain.Product, codeValue string, excludeID int) error {
 	for _, existingProduct := range products {
-		if existingProduct.CodeValue == codeValue {
-			return fmt.Errorf("code_value must be unique")
+		if existingProduct.CodeValue == codeValue && existingProduct.ID != excludeID {
+			return ErrCodeValueNotUnique
 		}
 	}
 	return nil
 }
 
-func (s *ProductService) AddProduct(newProduct domain.Product) {
-	s.repository.AddProduct(newProduct)
+// AddProduct checks that code_value is unique and stores the product as a draft, the repository assigns the id.
+func (s *ProductService) AddProduct(newProduct *domain.Product) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.ValidateUniqueCodeValue(s.repository.GetAllProducts(), newProduct.CodeValue, 0); err != nil {
+		return err
+	}
+
+	newProduct.SetStatus(domain.ProductDraft)
+
+	return s.repository.AddProduct(newProduct)
 }
 
-func (s *ProductService) UpdateProduct(updatedProduct domain.Product) error {
+// UpdateProduct replaces the product, except its status that is changed only by Publish and Archive.
+func (s *ProductService) UpdateProduct(updatedProduct *domain.Product) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	if err := s.ValidateProductFields(&updatedProduct); err != nil {
+	existing, err := s.repository.GetById(updatedProduct.ID)
+	if err != nil {
 		return err
 	}
 
-	if err := s.ValidateDateFormat(updatedProduct.Expiration); err != nil {
+	if err := s.ValidateProductFields(updatedProduct); err != nil {
 		return err
 	}
 
-	if err := s.ValidateUniqueCodeValue(s.repository.GetAllProducts(), updatedProduct.CodeValue); err != nil {
+	if err := s.ValidateUniqueCodeValue(s.repository.GetAllProducts(), updatedProduct.CodeValue, updatedProduct.ID); err != nil {
 		return err
 	}
 
-	return s.repository.UpdateProduct(updatedProduct)
+	updatedProduct.SetStatus(existing.Status)
+
+	if err := s.repository.UpdateProduct(*updatedProduct); err != nil {
+		return err
+	}
+	return s.adjustStock(existing, *updatedProduct, "product replaced")
 }
 
-func (s *ProductService) DeleteProduct(id int) error {
-	if id < 1 || id > len(s.repository.GetAllProducts()) {
-		return fmt.Errorf("product not found")
+// PatchProduct changes the fields of the product present in patch, only those are validated.
+func (s *ProductService) PatchProduct(id int, patch domain.ProductPatch) (p domain.Product, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if p, err = s.repository.GetById(id); err != nil {
+		return
 	}
-	s.repository.DeleteProduct(id)
-	return nil
+	existing := p
+
+	if err = patch.Validate(); err != nil {
+		return
+	}
+
+	if patch.CodeValue != nil {
+		if err = s.ValidateUniqueCodeValue(s.repository.GetAllProducts(), *patch.CodeValue, id); err != nil {
+			return
+		}
+		p.CodeValue = *patch.CodeValue
+	}
+	if patch.Name != nil {
+		p.Name = *patch.Name
+	}
+	if patch.Quantity != nil {
+		p.Quantity = *patch.Quantity
+	}
+	if patch.Expiration != nil {
+		p.Expiration = *patch.Expiration
+	}
+	if patch.Price != nil {
+		p.Price = *patch.Price
+	}
+
+	if err = s.repository.UpdateProduct(p); err != nil {
+		return
+	}
+	err = s.adjustStock(existing, p, "product patched")
+	return
+}
+
+func (s *ProductService) DeleteProduct(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.repository.DeleteProduct(id)
 }
@@ -0,0 +1,108 @@
+package product
+
+import (
+	"Practica/internal/domain"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+// day returns the date of day of January 2030.
+func day(d int) domain.Date {
+	return domain.NewDate(time.Date(2030, time.January, d, 0, 0, 0, 0, time.UTC))
+}
+
+// newExpirationService returns a service over products expiring on the 9th (yesterday), 10th (today),
+// 17th (today plus a week) and 18th of January 2030.
+func newExpirationService() *ProductService {
+	prods := []domain.Product{
+		searchProduct(1, "Milk", "MLK1", domain.ProductPublished, 3, 0.1, 18),
+		searchProduct(2, "Bread", "BRD1", domain.ProductPublished, 1, 0.2, 17),
+		searchProduct(3, "Eggs", "EGG1", domain.ProductPublished, 2, 0.1, 10),
+		searchProduct(4, "Yogurt", "YGT1", domain.ProductPublished, 1, 0.1, 9),
+		searchProduct(5, "Cheese", "CHS1", domain.ProductDraft, 1, 0.2, 9),
+		searchProduct(6, "Butter", "BTR1", domain.ProductArchived, 3, 0.1, 8),
+	}
+	return NewProductService(NewProductMemory(prods), NewStockMovementMemory())
+}
+
+func TestProductService_Expiring(t *testing.T) {
+	tests := []struct {
+		name    string
+		days    int
+		want    []int
+		wantErr error
+	}{
+		{name: "today and the last day are included", days: 7, want: []int{3, 2}},
+		{name: "only today", days: 0, want: []int{3}},
+		{name: "a day more", days: 8, want: []int{3, 2, 1}},
+		{name: "negative days", days: -1, wantErr: ErrInvalidQuery},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newExpirationService().Expiring(day(10), tt.days)
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Expiring() error = %v, want %v", err, tt.wantErr)
+			}
+			if ids := productIDs(got); tt.wantErr == nil && !slices.Equal(ids, tt.want) {
+				t.Fatalf("Expiring() = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
+
+func TestProductService_Expired(t *testing.T) {
+	s := newExpirationService()
+
+	// the products expiring today are not expired yet
+	got, err := s.Expired(day(10))
+	if err != nil {
+		t.Fatalf("Expired() error = %v", err)
+	}
+
+	if ids := productIDs(got); !slices.Equal(ids, []int{6, 4, 5}) {
+		t.Fatalf("Expired() = %v, want [6 4 5]", ids)
+	}
+}
+
+func TestProductService_ExpiredStock(t *testing.T) {
+	s := newExpirationService()
+
+	got, err := s.ExpiredStock(day(10))
+	if err != nil {
+		t.Fatalf("ExpiredStock() error = %v", err)
+	}
+
+	// 3 * 0.1 + 0.1 + 0.2 adds up to 0.6000000000000001 without rounding
+	if want := (domain.ExpiredStockReport{Count: 3, Quantity: 5, Value: 0.6}); got != want {
+		t.Fatalf("ExpiredStock() = %+v, want %+v", got, want)
+	}
+}
+
+func TestProductService_UnpublishExpired(t *testing.T) {
+	s := newExpirationService()
+
+	n, err := s.UnpublishExpired(day(10))
+	if err != nil {
+		t.Fatalf("UnpublishExpired() error = %v", err)
+	}
+	if n != 1 {
+		t.Fatalf("UnpublishExpired() = %d, want 1", n)
+	}
+
+	// only the published product expired before today is unpublished
+	want := map[int]string{1: domain.ProductPublished, 2: domain.ProductPublished, 3: domain.ProductPublished,
+		4: domain.ProductDraft, 5: domain.ProductDraft, 6: domain.ProductArchived}
+	for _, p := range s.GetAllProducts() {
+		if p.Status != want[p.ID] || p.IsPublished != (want[p.ID] == domain.ProductPublished) {
+			t.Fatalf("product %d is %s (published %t), want %s", p.ID, p.Status, p.IsPublished, want[p.ID])
+		}
+	}
+
+	// and nothing is left to unpublish
+	if n, err := s.UnpublishExpired(day(10)); err != nil || n != 0 {
+		t.Fatalf("UnpublishExpired() again = %d, %v, want 0", n, err)
+	}
+}
@@ -0,0 +1,83 @@
+package product
+
+import (
+	"Practica/internal/domain"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrInvalidMovement   = errors.New("invalid stock movement")
+	ErrInsufficientStock = errors.New("insufficient stock")
+)
+
+// MoveStock changes the quantity of the product and records the movement.
+// - in and out take the units that enter or leave, out can't leave the quantity negative
+// - adjust takes the counted quantity, that replaces the current one
+func (s *ProductService) MoveStock(id int, kind string, quantity int, reason string) (m domain.StockMovement, err error) {
+	switch {
+	case kind != domain.StockIn && kind != domain.StockOut && kind != domain.StockAdjust:
+		err = fmt.Errorf("%w: unknown kind %q", ErrInvalidMovement, kind)
+	case kind == domain.StockAdjust && quantity < 0:
+		err = fmt.Errorf("%w: quantity can't be negative", ErrInvalidMovement)
+	case kind != domain.StockAdjust && quantity <= 0:
+		err = fmt.Errorf("%w: quantity must be positive", ErrInvalidMovement)
+	case reason == "":
+		err = fmt.Errorf("%w: reason must be provided", ErrInvalidMovement)
+	}
+	if err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, err := s.repository.GetById(id)
+	if err != nil {
+		return
+	}
+	delta := quantity
+	switch kind {
+	case domain.StockOut:
+		delta = -quantity
+	case domain.StockAdjust:
+		delta = quantity - p.Quantity
+	}
+	if p.Quantity+delta < 0 {
+		err = fmt.Errorf("%w: %d units in stock", ErrInsufficientStock, p.Quantity)
+		return
+	}
+
+	p.Quantity += delta
+	if err = s.repository.UpdateProduct(p); err != nil {
+		return
+	}
+	return s.addMovement(p, kind, delta, reason)
+}
+
+// addMovement records the change delta of the quantity of p, already stored.
+// It is called with s.mu held.
+func (s *ProductService) addMovement(p domain.Product, kind string, delta int, reason string) (m domain.StockMovement, err error) {
+	m = domain.StockMovement{ProductID: p.ID, Kind: kind, Delta: delta, Quantity: p.Quantity, Reason: reason, Time: time.Now()}
+	err = s.movements.AddMovement(&m)
+	return
+}
+
+// adjustStock records the change of the quantity from before to after, already stored, as an adjust movement.
+// It is called with s.mu held by the updates that replace the quantity.
+func (s *ProductService) adjustStock(before, after domain.Product, reason string) error {
+	if after.Quantity == before.Quantity {
+		return nil
+	}
+	_, err := s.addMovement(after, domain.StockAdjust, after.Quantity-before.Quantity, reason)
+	return err
+}
+
+// Movements returns the stock movements of the product, oldest first.
+func (s *ProductService) Movements(id int) ([]domain.StockMovement, error) {
+	if _, err := s.repository.GetById(id); err != nil {
+		return nil, err
+	}
+	return s.movements.GetMovements(id)
+}
@@ -0,0 +1,49 @@
+package product
+
+import (
+	"Practica/internal/domain"
+	"sync"
+)
+
+// StockMovementRepository is the storage of the stock movements.
+type StockMovementRepository interface {
+	// AddMovement assigns the next id to the movement and stores it
+	AddMovement(m *domain.StockMovement) error
+	// GetMovements returns the movements of the product, oldest first
+	GetMovements(productID int) ([]domain.StockMovement, error)
+}
+
+// StockMovementMemory is a StockMovementRepository that keeps the movements in a slice.
+// It is safe for concurrent use.
+type StockMovementMemory struct {
+	mu        sync.RWMutex
+	movements []domain.StockMovement
+	lastID    int
+}
+
+func NewStockMovementMemory() *StockMovementMemory {
+	return &StockMovementMemory{}
+}
+
+func (r *StockMovementMemory) AddMovement(m *domain.StockMovement) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastID++
+	m.ID = r.lastID
+	r.movements = append(r.movements, *m)
+	return nil
+}
+
+func (r *StockMovementMemory) GetMovements(productID int) ([]domain.StockMovement, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	movements := make([]domain.StockMovement, 0)
+	for _, m := range r.movements {
+		if m.ProductID == productID {
+			movements = append(movements, m)
+		}
+	}
+	return movements, nil
+}
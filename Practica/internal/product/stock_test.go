@@ -0,0 +1,100 @@
+package product
+
+import (
+	"Practica/internal/domain"
+	"Practica/pkg"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newStockProduct returns a valid product with quantity units in stock.
+func newStockProduct(id, quantity int) domain.Product {
+	return domain.Product{ID: id, Name: "Milk", Quantity: quantity, CodeValue: "MLK1", Status: domain.ProductDraft,
+		Expiration: domain.NewDate(time.Now().AddDate(1, 0, 0)), Price: 2.5}
+}
+
+func TestProductService_SoldOutProduct(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "products.json")
+	s := NewProductService(NewProductFile(path, []domain.Product{newStockProduct(1, 5)}), NewStockMovementMemory())
+
+	// sell every unit
+	if _, err := s.MoveStock(1, domain.StockOut, 5, "sold"); err != nil {
+		t.Fatalf("MoveStock() error = %v", err)
+	}
+
+	// a sold out product can be replaced and patched
+	p := newStockProduct(1, 0)
+	p.Name = "Whole milk"
+	if err := s.UpdateProduct(&p); err != nil {
+		t.Fatalf("UpdateProduct() of a sold out product error = %v", err)
+	}
+	name := "Skimmed milk"
+	if _, err := s.PatchProduct(1, domain.ProductPatch{Name: &name}); err != nil {
+		t.Fatalf("PatchProduct() of a sold out product error = %v", err)
+	}
+
+	// and loaded back from the file
+	prods, err := pkg.LoadProducts(path)
+	if err != nil {
+		t.Fatalf("LoadProducts() error = %v", err)
+	}
+	if len(prods) != 1 || prods[0].Quantity != 0 || prods[0].Name != name {
+		t.Fatalf("LoadProducts() = %+v, want the sold out product", prods)
+	}
+}
+
+func TestProductService_QuantityUpdatesRecordMovements(t *testing.T) {
+	tests := []struct {
+		name   string
+		update func(s *ProductService) error
+		// want is the adjust movement recorded, none when zero
+		want domain.StockMovement
+	}{
+		{name: "replace", update: func(s *ProductService) error {
+			p := newStockProduct(1, 7)
+			return s.UpdateProduct(&p)
+		}, want: domain.StockMovement{Delta: 2, Quantity: 7, Reason: "product replaced"}},
+		{name: "replace without quantity change", update: func(s *ProductService) error {
+			p := newStockProduct(1, 5)
+			p.Price = 3
+			return s.UpdateProduct(&p)
+		}},
+		{name: "patch", update: func(s *ProductService) error {
+			quantity := 0
+			_, err := s.PatchProduct(1, domain.ProductPatch{Quantity: &quantity})
+			return err
+		}, want: domain.StockMovement{Delta: -5, Quantity: 0, Reason: "product patched"}},
+		{name: "import", update: func(s *ProductService) error {
+			_, err := s.ImportProducts([]ImportRow{{Product: newStockProduct(0, 9)}})
+			return err
+		}, want: domain.StockMovement{Delta: 4, Quantity: 9, Reason: "product imported"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewProductService(NewProductMemory([]domain.Product{newStockProduct(1, 5)}), NewStockMovementMemory())
+
+			if err := tt.update(s); err != nil {
+				t.Fatalf("update error = %v", err)
+			}
+
+			movements, err := s.Movements(1)
+			if err != nil {
+				t.Fatalf("Movements() error = %v", err)
+			}
+			if tt.want == (domain.StockMovement{}) {
+				if len(movements) != 0 {
+					t.Fatalf("Movements() = %+v, want none", movements)
+				}
+				return
+			}
+			if len(movements) != 1 {
+				t.Fatalf("Movements() = %+v, want 1", movements)
+			}
+			m := movements[0]
+			if m.Kind != domain.StockAdjust || m.Delta != tt.want.Delta || m.Quantity != tt.want.Quantity || m.Reason != tt.want.Reason {
+				t.Fatalf("movement = %+v, want an adjust of %d to %d for %q", m, tt.want.Delta, tt.want.Quantity, tt.want.Reason)
+			}
+		})
+	}
+}
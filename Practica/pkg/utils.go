@@ -2,21 +2,119 @@ package pkg
 
 import (
 	"Practica/internal/domain"
+	"bytes"
 	"encoding/json"
-	"io/ioutil"
+	"errors"
+	"fmt"
+	"os"
 )
 
-func FullfilDB(filePath string) []domain.Product {
-	file, err := ioutil.ReadFile(filePath)
-	if err != nil {
-		panic(err)
+// LoadError is an error of the products file, at a position of the file.
+type LoadError struct {
+	Path   string
+	Line   int
+	Column int
+	// Record is the position of the product in the file, from 1, or 0 when the file can't be read.
+	Record int
+	Err    error
+}
+
+func (e *LoadError) Error() string {
+	if e.Record == 0 {
+		return fmt.Sprintf("%s:%d:%d: %v", e.Path, e.Line, e.Column, e.Err)
 	}
+	return fmt.Sprintf("%s:%d:%d: product %d: %v", e.Path, e.Line, e.Column, e.Record, e.Err)
+}
+
+func (e *LoadError) Unwrap() error {
+	return e.Err
+}
 
-	var products []domain.Product
-	err = json.Unmarshal(file, &products)
+// LoadProducts reads the json array of products of filePath.
+// The products are validated like ValidateProductFields, and their ids and code_values must be unique.
+// Invalid products are skipped: the valid ones are returned along with the errors of the others.
+// When the file can't be read at all no product is returned.
+func LoadProducts(filePath string) ([]domain.Product, error) {
+	data, err := os.ReadFile(filePath)
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("could not read products: %w", err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+		return nil, loadError(filePath, data, recordStart(data, 0), 0, errors.New("expected a json array of products"))
+	}
+
+	products := make([]domain.Product, 0)
+	var errs []error
+	ids, codeValues := make(map[int]int), make(map[string]int)
+	for record := 1; dec.More(); record++ {
+		start := recordStart(data, dec.InputOffset())
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, loadError(filePath, data, dec.InputOffset(), 0, fmt.Errorf("invalid json: %w", err))
+		}
+
+		var p domain.Product
+		err := json.Unmarshal(raw, &p)
+		switch {
+		case err != nil:
+			err = fmt.Errorf("invalid product: %w", err)
+		case p.ID <= 0:
+			err = errors.New("id must be positive")
+		case ids[p.ID] != 0:
+			err = fmt.Errorf("id %d is also the id of product %d", p.ID, ids[p.ID])
+		case codeValues[p.CodeValue] != 0:
+			err = fmt.Errorf("code_value %q is also the code_value of product %d", p.CodeValue, codeValues[p.CodeValue])
+		default:
+			err = p.Validate()
+		}
+		if err != nil {
+			errs = append(errs, loadError(filePath, data, start, record, err))
+			continue
+		}
+
+		if p.Status == "" {
+			p.SetStatus(domain.LegacyStatus(p.IsPublished))
+		}
+		ids[p.ID], codeValues[p.CodeValue] = record, record
+		products = append(products, p)
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, loadError(filePath, data, dec.InputOffset(), 0, fmt.Errorf("invalid json: %w", err))
+	}
+
+	return products, errors.Join(errs...)
+}
+
+// recordStart returns the offset of the next value of an array from offset, past the spaces and the comma.
+func recordStart(data []byte, offset int64) int64 {
+	for offset < int64(len(data)) {
+		switch data[offset] {
+		case ' ', '\t', '\r', '\n', ',':
+			offset++
+		default:
+			return offset
+		}
 	}
+	return offset
+}
 
-	return products
+// loadError returns the error err at offset of data.
+func loadError(filePath string, data []byte, offset int64, record int, err error) *LoadError {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		offset = syntaxErr.Offset
+		// the offset of a syntax error is past the invalid byte, unless the file ended early
+		if offset > 0 && offset <= int64(len(data)) && !bytes.ContainsAny(data[offset-1:offset], " \t\r\n") {
+			offset--
+		}
+	}
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := len(before) - bytes.LastIndexByte(before, '\n')
+	return &LoadError{Path: filePath, Line: line, Column: column, Record: record, Err: err}
 }
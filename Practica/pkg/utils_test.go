@@ -0,0 +1,144 @@
+package pkg
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeProducts writes content to a products file of a temporary directory and returns its path.
+func writeProducts(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "products.json")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// loadErrors returns the load errors joined in err.
+func loadErrors(t *testing.T, err error) []*LoadError {
+	t.Helper()
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		var le *LoadError
+		if !errors.As(err, &le) {
+			t.Fatalf("error = %v, want load errors", err)
+		}
+		return []*LoadError{le}
+	}
+	var errs []*LoadError
+	for _, err := range joined.Unwrap() {
+		var le *LoadError
+		if !errors.As(err, &le) {
+			t.Fatalf("error = %v, want a load error", err)
+		}
+		errs = append(errs, le)
+	}
+	return errs
+}
+
+const (
+	milk  = `{"id":1,"name":"Milk","quantity":10,"code_value":"MLK1","is_published":true,"expiration":"31/12/2030","price":2.5}`
+	oil   = `{"id":2,"name":"Oil","quantity":3,"code_value":"OIL1","expiration":"31/12/2030","price":10}`
+	bread = `{"id":3,"name":"Bread","quantity":5,"code_value":"BRD1","status":"archived","expiration":"31/12/2030","price":1.2}`
+)
+
+func TestLoadProducts(t *testing.T) {
+	path := writeProducts(t, "[\n  "+milk+",\n  "+oil+",\n  "+bread+"\n]\n")
+
+	products, err := LoadProducts(path)
+	if err != nil {
+		t.Fatalf("LoadProducts() error = %v", err)
+	}
+
+	if len(products) != 3 {
+		t.Fatalf("LoadProducts() = %+v, want 3 products", products)
+	}
+	// the status of the products stored before the lifecycle comes from is_published
+	for i, want := range []string{"published", "draft", "archived"} {
+		if products[i].Status != want || products[i].IsPublished != (want == "published") {
+			t.Fatalf("product %d is %s (published %t), want %s", products[i].ID, products[i].Status, products[i].IsPublished, want)
+		}
+	}
+}
+
+func TestLoadProducts_Unreadable(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		// wantLine and wantColumn are the position of the error in the file
+		wantLine, wantColumn int
+		wantErr              string
+	}{
+		{name: "not an array", content: "\n  {\"products\": []}", wantLine: 2, wantColumn: 3, wantErr: "expected a json array"},
+		{name: "empty", content: "", wantLine: 1, wantColumn: 1, wantErr: "expected a json array"},
+		{name: "syntax error", content: "[\n  " + milk + ",\n  {\"id\":2 \"name\":\"Oil\"}\n]", wantLine: 3, wantColumn: 11, wantErr: "invalid json"},
+		{name: "invalid literal", content: "[\n  {\"id\":tru}]", wantLine: 2, wantColumn: 12, wantErr: "invalid json"},
+		{name: "unterminated array", content: "[\n  " + milk + "\n", wantLine: 3, wantColumn: 1, wantErr: "invalid json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			products, err := LoadProducts(writeProducts(t, tt.content))
+
+			var le *LoadError
+			if !errors.As(err, &le) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("LoadProducts() error = %v, want a load error %q", err, tt.wantErr)
+			}
+			if le.Line != tt.wantLine || le.Column != tt.wantColumn || le.Record != 0 {
+				t.Fatalf("error at %d:%d of product %d, want %d:%d of the file", le.Line, le.Column, le.Record, tt.wantLine, tt.wantColumn)
+			}
+			if products != nil {
+				t.Fatalf("LoadProducts() = %+v, want no products", products)
+			}
+		})
+	}
+
+	t.Run("missing file", func(t *testing.T) {
+		products, err := LoadProducts(filepath.Join(t.TempDir(), "missing.json"))
+
+		if !errors.Is(err, fs.ErrNotExist) || products != nil {
+			t.Fatalf("LoadProducts() = %v, %v, want no products and %v", products, err, fs.ErrNotExist)
+		}
+	})
+}
+
+func TestLoadProducts_Partial(t *testing.T) {
+	path := writeProducts(t, "[\n"+
+		milk+",\n"+
+		// duplicated id
+		`{"id":1,"name":"Oil","quantity":3,"code_value":"OIL1","expiration":"31/12/2030","price":10},`+"\n"+
+		oil+",\n"+
+		// duplicated code_value
+		`{"id":4,"name":"Olive oil","quantity":3,"code_value":"OIL1","expiration":"31/12/2030","price":12},`+"\n"+
+		// invalid fields
+		`{"id":5,"name":"","quantity":3,"code_value":"EGG1","expiration":"31/12/2030","price":3},`+"\n"+
+		`{"id":6,"name":"Eggs","quantity":3,"code_value":"EGG1","expiration":"31/12/2030","price":3}`+"\n"+
+		"]")
+
+	products, err := LoadProducts(path)
+
+	if len(products) != 3 || products[0].ID != 1 || products[1].ID != 2 || products[2].ID != 6 {
+		t.Fatalf("LoadProducts() = %+v, want the valid products 1, 2 and 6", products)
+	}
+	errs := loadErrors(t, err)
+	want := []struct {
+		line, record int
+		err          string
+	}{
+		{line: 3, record: 2, err: "id 1 is also the id of product 1"},
+		{line: 5, record: 4, err: `code_value "OIL1" is also the code_value of product 3`},
+		{line: 6, record: 5, err: "fields"},
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("LoadProducts() error = %v, want %d errors", err, len(want))
+	}
+	for i, w := range want {
+		if e := errs[i]; e.Path != path || e.Line != w.line || e.Column != 1 || e.Record != w.record || !strings.Contains(e.Error(), w.err) {
+			t.Fatalf("error %d = %v, want %q at %d:1 of product %d", i, e, w.err, w.line, w.record)
+		}
+	}
+}